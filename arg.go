@@ -0,0 +1,262 @@
+package clip
+
+import (
+	"fmt"
+)
+
+// argDef is a positional command-line argument.
+//
+// Methods are defined for use in help text.
+type argDef struct {
+	name     string
+	summary  string
+	variadic bool
+	min      int
+	max      int
+	values   []string
+
+	setFunc func(string) error
+	changed bool
+}
+
+// Name returns the name of the argument.
+func (a *argDef) Name() string { return a.name }
+
+// Summary returns a one-line description of the argument.
+func (a *argDef) Summary() string { return a.summary }
+
+// Values returns the list of allowed values, if any.
+func (a *argDef) Values() []string { return a.values }
+
+// Usage returns the argument as it appears in a usage line.
+func (a *argDef) Usage() string {
+	usage := "<" + a.name + ">"
+	if a.variadic {
+		usage += "..."
+	}
+	if a.optional() {
+		usage = "[" + usage + "]"
+	}
+	return usage
+}
+
+// optional returns whether the argument can be omitted.
+func (a *argDef) optional() bool { return a.min == 0 }
+
+// set assigns a string value to an argument.
+func (a *argDef) set(v string) error {
+	if len(a.values) > 0 {
+		if err := checkChoice(v, a.values); err != nil {
+			return fmt.Errorf("argument %w", err)
+		}
+	}
+
+	if err := a.setFunc(v); err != nil {
+		return fmt.Errorf("invalid argument for <%s>: %w", a.name, err)
+	}
+	a.changed = true
+	return nil
+}
+
+// parseArgs assigns positional arguments to their definitions in order.
+//
+// Any arguments left over after every definition is satisfied are returned.
+func parseArgs(defs []*argDef, args []string) ([]string, error) {
+	for _, a := range defs {
+		n := len(args)
+		if a.max > 0 {
+			n = min(n, a.max)
+		}
+
+		switch {
+		case n >= a.min:
+		case n == 0:
+			return nil, fmt.Errorf("missing required argument: %s", a.Usage())
+		default:
+			return nil, fmt.Errorf(
+				"not enough arguments for %s: want at least %d, got %d",
+				a.Usage(), a.min, n,
+			)
+		}
+
+		for _, v := range args[:n] {
+			if err := a.set(v); err != nil {
+				return nil, err
+			}
+		}
+		args = args[n:]
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	return args, nil
+}
+
+// StringArg creates a new string argument.
+func StringArg(value *string, name string, options ...ArgOption) CommandOption {
+	return func(c *commandConfig) {
+		a := newArg(name, false, options...)
+		a.setFunc = func(s string) error {
+			*value = s
+			return nil
+		}
+
+		c.addArg(a)
+	}
+}
+
+// StringsArg creates a new variadic string argument.
+//
+// A variadic argument consumes the remaining arguments, up to [ArgMax], and
+// must be the last argument defined on a command. It requires at least one value unless
+// [ArgOptional] or [ArgMin] is passed.
+func StringsArg(value *[]string, name string, options ...ArgOption) CommandOption {
+	return func(c *commandConfig) {
+		a := newArg(name, true, options...)
+		a.setFunc = func(s string) error {
+			if !a.changed {
+				*value = nil
+			}
+			*value = append(*value, s)
+			return nil
+		}
+
+		c.addArg(a)
+	}
+}
+
+// IntArg creates a new integer argument.
+func IntArg(value *int, name string, options ...ArgOption) CommandOption {
+	return func(c *commandConfig) {
+		a := newArg(name, false, options...)
+		a.setFunc = func(s string) error {
+			n, err := parseInt(s, 0)
+			if err != nil {
+				return err
+			}
+
+			*value = int(n)
+			return nil
+		}
+
+		c.addArg(a)
+	}
+}
+
+// TextVarArg creates a new argument based on [encoding.TextMarshaler] and
+// [encoding.TextUnmarshaler].
+func TextVarArg(value TextVar, name string, options ...ArgOption) CommandOption {
+	return func(c *commandConfig) {
+		a := newArg(name, false, options...)
+		a.setFunc = func(s string) error {
+			return value.UnmarshalText([]byte(s))
+		}
+
+		c.addArg(a)
+	}
+}
+
+// ArgOption is an option for creating an argument.
+type ArgOption func(*argConfig)
+
+type argConfig struct {
+	summary  string
+	optional bool
+	hasMin   bool
+	min      int
+	hasMax   bool
+	max      int
+	values   []string
+}
+
+func newArg(name string, variadic bool, options ...ArgOption) *argDef {
+	c := argConfig{}
+	for _, o := range options {
+		o(&c)
+	}
+
+	switch {
+	case (c.hasMin || c.hasMax) && !variadic:
+		panic(fmt.Sprintf("argument %q cannot have a minimum or maximum unless it is variadic", name))
+	case c.min < 0 || c.max < 0:
+		panic(fmt.Sprintf("argument %q cannot have a negative minimum or maximum", name))
+	case c.max > 0 && c.min > c.max:
+		panic(fmt.Sprintf(
+			"argument %q cannot have a minimum of %d greater than its maximum of %d",
+			name, c.min, c.max,
+		))
+	case c.optional && c.min > 0:
+		panic(fmt.Sprintf("argument %q cannot be optional with a minimum of %d", name, c.min))
+	}
+
+	a := &argDef{
+		name:     name,
+		summary:  c.summary,
+		variadic: variadic,
+		min:      1,
+		max:      1,
+		values:   c.values,
+	}
+
+	if c.optional {
+		a.min = 0
+	}
+
+	if variadic {
+		a.max = c.max
+		if c.hasMin {
+			a.min = c.min
+		}
+	}
+
+	return a
+}
+
+// ArgOptional allows an argument to be omitted.
+//
+// Since arguments are positional, every argument following an optional
+// argument must also be optional. Panics if combined with a non-zero
+// [ArgMin].
+func ArgOptional(c *argConfig) {
+	c.optional = true
+}
+
+// ArgSummary adds a one-line description to an argument.
+func ArgSummary(summary string) ArgOption {
+	return func(c *argConfig) {
+		c.summary = summary
+	}
+}
+
+// ArgValues restricts an argument to a list of allowed values.
+func ArgValues(values []string) ArgOption {
+	return func(c *argConfig) {
+		c.values = values
+	}
+}
+
+// ArgMin sets the minimum number of values for a variadic argument.
+// Panics if used on an argument that is not variadic.
+//
+// A minimum of zero makes the argument optional.
+func ArgMin(n int) ArgOption {
+	return func(c *argConfig) {
+		c.hasMin = true
+		c.min = n
+	}
+}
+
+// ArgMax sets the maximum number of values for a variadic argument.
+// Panics if used on an argument that is not variadic, or if the maximum is
+// less than the minimum.
+//
+// Any arguments beyond the maximum are treated as unexpected. A maximum of
+// zero, the default, allows any number of values.
+func ArgMax(n int) ArgOption {
+	return func(c *argConfig) {
+		c.hasMax = true
+		c.max = n
+	}
+}
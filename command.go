@@ -18,6 +18,11 @@ type Command struct {
 	stdout      io.Writer
 	stderr      io.Writer
 
+	warningHandler     func(*Context, string)
+	strictDeprecations bool
+
+	args            []*argDef
+	restArgs        *[]string
 	flagSet         *flagSet
 	visibleCommands []*Command
 	subCommandMap   map[string]*Command
@@ -42,6 +47,20 @@ func NewCommand(name string, options ...CommandOption) *Command {
 		o(&c)
 	}
 
+	if (len(c.args) > 0 || c.restArgs != nil) && len(c.subCommandMap) > 0 {
+		panic(fmt.Sprintf("command %q cannot have both arguments and sub-commands", name))
+	}
+
+	if c.flagSet.interspersed && len(c.subCommandMap) > 0 {
+		panic(fmt.Sprintf("command %q cannot have both interspersed flags and sub-commands", name))
+	}
+
+	c.flagSet.name = name
+	c.flagSet.checkGroupNames()
+	for _, subCmd := range c.subCommandMap {
+		subCmd.flagSet.parent = c.flagSet
+	}
+
 	applyConditionalDefaults(&c)
 
 	return &Command{
@@ -53,6 +72,11 @@ func NewCommand(name string, options ...CommandOption) *Command {
 		stdout:      c.stdout,
 		stderr:      c.stderr,
 
+		warningHandler:     c.warningHandler,
+		strictDeprecations: c.strictDeprecations,
+
+		args:            c.args,
+		restArgs:        c.restArgs,
 		flagSet:         c.flagSet,
 		visibleCommands: c.visibleCommands,
 		subCommandMap:   c.subCommandMap,
@@ -71,6 +95,12 @@ type commandConfig struct {
 	stdout      io.Writer
 	stderr      io.Writer
 
+	warningHandler     func(*Context, string)
+	strictDeprecations bool
+	debugFlags         bool
+
+	args            []*argDef
+	restArgs        *[]string
 	flagSet         *flagSet
 	visibleCommands []*Command
 	subCommandMap   map[string]*Command
@@ -94,6 +124,17 @@ func applyConditionalDefaults(c *commandConfig) {
 
 		ToggleFlag("help", options...)(c)
 	}
+
+	if c.debugFlags && !c.flagSet.Has("debug-flags") {
+		ToggleFlag(
+			"debug-flags",
+			FlagHidden,
+			FlagPersistent,
+			FlagAction(func(ctx *Context) error {
+				return writeFlagSources(ctx.Stdout(), ctx)
+			}),
+		)(c)
+	}
 }
 
 // CommandHidden hides a command from documentation.
@@ -101,6 +142,72 @@ func CommandHidden(c *commandConfig) {
 	c.hidden = true
 }
 
+// CommandDebugFlags adds a hidden --debug-flags flag to the command and its
+// sub-commands.
+//
+// Passing the flag prints the value of each flag and where the value came
+// from, such as the command-line or an environment variable, instead of
+// running the command. This can help diagnose unexpected configuration.
+//
+// The output includes values set by environment variables and value sources,
+// which may contain secrets. Use [FlagSensitive] to mask the values of flags
+// such as passwords and tokens.
+func CommandDebugFlags(c *commandConfig) {
+	c.debugFlags = true
+}
+
+// CommandValueSource sets a source of flag values, such as a config file.
+//
+// The source is consulted for any flag not set on the command-line or by an
+// environment variable, and applies to sub-commands unless they set their
+// own. Values are looked up by a key based on the command path and flag name,
+// such as "deploy.region" for the --region flag of the deploy sub-command.
+func CommandValueSource(source ValueSource) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.valueSource = source
+	}
+}
+
+// CommandEnvFile loads environment variables for flags from env files, such as
+// ".env".
+//
+// Variables from the files are used only to set flags with [FlagEnv], and do
+// not modify the process environment. Variables already set in the process
+// environment take precedence, as do files listed earlier. Missing files are
+// ignored. Env files apply to sub-commands, which may list their own files to
+// take precedence. Successive calls will add to earlier values.
+func CommandEnvFile(paths ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.envFiles = append(c.flagSet.envFiles, paths...)
+	}
+}
+
+// CommandEnvPrefix sets a prefix used to derive environment variables for
+// flags.
+//
+// Each flag without a [FlagEnv] can be set by an environment variable named
+// after the prefix, the name of each sub-command below the command that set
+// the prefix, and the flag's name, such as MYAPP_DEPLOY_REGION for the
+// --region flag of the deploy sub-command. Names are upper-cased, and dashes
+// are replaced with underscores. The prefix applies to sub-commands unless
+// they set their own, such as DEPLOY_REGION if the deploy sub-command sets the
+// prefix DEPLOY.
+func CommandEnvPrefix(prefix string) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.envPrefix = prefix
+	}
+}
+
+// CommandInterspersedFlags allows flags to be passed after positional
+// arguments.
+//
+// By default, flag parsing stops at the first positional argument. With this
+// option, every argument is checked for flags until a "--" terminator. This
+// cannot be used on commands with sub-commands.
+func CommandInterspersedFlags(c *commandConfig) {
+	c.flagSet.interspersed = true
+}
+
 // CommandSummary adds a one-line description to a command.
 func CommandSummary(summary string) CommandOption {
 	return func(c *commandConfig) {
@@ -122,6 +229,16 @@ func CommandAction(action func(*Context) error) CommandOption {
 	}
 }
 
+// CommandArgs allows a command to accept arbitrary arguments.
+//
+// Any arguments not consumed by an explicitly defined argument are assigned
+// to the slice. Without this option, unexpected arguments are an error.
+func CommandArgs(args *[]string) CommandOption {
+	return func(c *commandConfig) {
+		c.restArgs = args
+	}
+}
+
 // SubCommand adds a sub-command.
 func SubCommand(name string, options ...CommandOption) CommandOption {
 	subCmd := NewCommand(name, options...)
@@ -152,11 +269,49 @@ func CommandStderr(writer io.Writer) CommandOption {
 	}
 }
 
+// CommandWarningHandler sets the behavior when a warning occurs, such as when
+// a deprecated flag is used.
+//
+// By default, warnings are printed to the command's stderr. The handler also
+// applies to sub-commands.
+func CommandWarningHandler(handler func(ctx *Context, message string)) CommandOption {
+	return func(c *commandConfig) {
+		c.warningHandler = handler
+	}
+}
+
+// CommandStrictDeprecations sets whether using deprecated flags is an error
+// instead of a warning.
+//
+// Strict deprecations also apply to sub-commands. This can be used to catch
+// deprecated usage in automated environments:
+//
+//	clip.CommandStrictDeprecations(os.Getenv("CI") != "")
+func CommandStrictDeprecations(strict bool) CommandOption {
+	return func(c *commandConfig) {
+		c.strictDeprecations = strict
+	}
+}
+
 // addFlag registers a flag on a command.
 //
 // It is called after registering the flag on the command's flagset.
 func (c *commandConfig) addFlag(f *flagDef) {
-	c.flagSet.byName[f.name] = f
+	if f.negatable && !f.boolean {
+		panic(fmt.Sprintf("flag %q cannot be negatable unless it is a boolean flag", f.name))
+	}
+
+	f.owner = c.flagSet
+	c.addFlagName(f.name, f)
+	if f.negatable {
+		c.addFlagName(f.negatedName(), f)
+	}
+	for _, alias := range f.aliases {
+		c.addFlagName(alias, f)
+	}
+	for _, alias := range f.deprecatedAliases {
+		c.addFlagName(alias, f)
+	}
 	if f.short != "" {
 		c.flagSet.byShortName[f.short] = f
 	}
@@ -175,6 +330,30 @@ func (c *commandConfig) addFlag(f *flagDef) {
 	}
 }
 
+// addFlagName registers an additional name for a flag.
+func (c *commandConfig) addFlagName(name string, f *flagDef) {
+	if c.flagSet.Has(name) {
+		panic(fmt.Sprintf("a flag with name %q already exists", name))
+	}
+
+	c.flagSet.byName[name] = f
+}
+
+// addArg registers a positional argument on a command.
+func (c *commandConfig) addArg(a *argDef) {
+	if len(c.args) > 0 {
+		last := c.args[len(c.args)-1]
+		if last.variadic {
+			panic(fmt.Sprintf("argument %q cannot follow a variadic argument", a.name))
+		}
+		if !a.optional() && last.optional() {
+			panic(fmt.Sprintf("required argument %q cannot follow an optional argument", a.name))
+		}
+	}
+
+	c.args = append(c.args, a)
+}
+
 // Name is the name of the command.
 func (cmd *Command) Name() string { return cmd.name }
 
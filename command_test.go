@@ -321,21 +321,68 @@ func TestFlagAction_error(t *testing.T) {
 	g.Should(be.True(flagValue))
 }
 
+func TestCommandDebugFlags(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("APP_REGION", "staging")
+	t.Setenv("APP_TOKEN", "hunter2")
+
+	var (
+		verbose bool
+		region  string
+		count   = 3
+	)
+
+	wasCalled := false
+	output := new(bytes.Buffer)
+	cmd := NewCommand(
+		"app",
+		CommandStdout(output),
+		CommandDebugFlags,
+		BoolFlag(&verbose, "verbose", FlagPersistent),
+		SubCommand(
+			"deploy",
+			StringFlag(&region, "region", FlagEnv("APP_REGION")),
+			IntFlag(&count, "count"),
+			StringFlag(new(string), "secret", FlagHidden),
+			StringFlag(new(string), "token", FlagEnv("APP_TOKEN"), FlagSensitive),
+			CommandAction(func(*Context) error {
+				wasCalled = true
+				return nil
+			}),
+		),
+	)
+
+	g.NoError(cmd.Execute([]string{"app", "deploy", "--verbose", "--debug-flags"}))
+	g.Should(be.False(wasCalled))
+	g.Should(be.Equal(output.String(), `--count=3 (default)
+--region=staging (env var APP_REGION)
+--token=<redacted> (env var APP_TOKEN)
+--verbose=true (command line)
+`))
+
+	helpOutput := new(bytes.Buffer)
+	g.NoError(WriteHelp(helpOutput, &Context{command: cmd}))
+	g.ShouldNot(be.StringContaining(helpOutput.String(), "debug-flags"))
+}
+
 func TestCommandArgs(t *testing.T) {
 	g := ghost.New(t)
 
 	cmdName := "foo"
 	args := []string{"a", "b", "c"}
 
+	var gotArgs []string
 	wasCalled := false
 	action := func(ctx *Context) error {
 		wasCalled = true
-		g.Should(be.DeepEqual(ctx.args(), args))
+		g.Should(be.DeepEqual(ctx.Args(), args))
 		return nil
 	}
 
 	command := NewCommand(
 		cmdName,
+		CommandArgs(&gotArgs),
 		CommandAction(action),
 	)
 
@@ -343,6 +390,75 @@ func TestCommandArgs(t *testing.T) {
 	err := command.Execute(cliArgs)
 	g.NoError(err)
 	g.Should(be.True(wasCalled))
+	g.Should(be.DeepEqual(gotArgs, args))
+}
+
+func TestCommandArgsAfterStringArg(t *testing.T) {
+	g := ghost.New(t)
+
+	var name string
+	var rest []string
+	command := NewCommand(
+		"foo",
+		StringArg(&name, "name"),
+		CommandArgs(&rest),
+		CommandAction(func(*Context) error { return nil }),
+	)
+
+	g.NoError(command.Execute([]string{"foo", "alice", "bob", "carl"}))
+	g.Should(be.Equal(name, "alice"))
+	g.Should(be.DeepEqual(rest, []string{"bob", "carl"}))
+}
+
+func TestCommandUnexpectedArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"foo", "child", "bar"},
+			err:  "unexpected argument: bar",
+		},
+		{
+			args: []string{"foo", "child", "bar", "baz"},
+			err:  "unexpected argument: bar",
+		},
+		{
+			args: []string{"foo", "child", "--", "bar"},
+			err:  "unexpected argument: bar",
+		},
+		{
+			args: []string{"foo", "named", "alice", "bob"},
+			err:  "unexpected argument: bob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			wasCalled := false
+			action := func(*Context) error {
+				wasCalled = true
+				return nil
+			}
+
+			cmd := NewCommand(
+				"foo",
+				SubCommand("child", CommandAction(action)),
+				SubCommand(
+					"named",
+					StringArg(new(string), "name"),
+					CommandAction(action),
+				),
+			)
+
+			err := cmd.Execute(tt.args)
+			g.Should(be.ErrorEqual(err, tt.err))
+			g.Should(be.Equal(exitCode(err), 2))
+			g.Should(be.False(wasCalled))
+		})
+	}
 }
 
 func TestSubCommandArgs(t *testing.T) {
@@ -352,10 +468,11 @@ func TestSubCommandArgs(t *testing.T) {
 	subCmdName := "bar"
 	args := []string{"a", "b", "c"}
 
+	var gotArgs []string
 	subCmdWasCalled := false
 	subCmdAction := func(ctx *Context) error {
 		subCmdWasCalled = true
-		g.Should(be.DeepEqual(ctx.args(), args))
+		g.Should(be.DeepEqual(ctx.Args(), args))
 		return nil
 	}
 	defer func() { g.Should(be.True(subCmdWasCalled)) }()
@@ -372,6 +489,7 @@ func TestSubCommandArgs(t *testing.T) {
 		CommandAction(cmdAction),
 		SubCommand(
 			subCmdName,
+			CommandArgs(&gotArgs),
 			CommandAction(subCmdAction),
 		),
 	)
@@ -379,6 +497,7 @@ func TestSubCommandArgs(t *testing.T) {
 	cliArgs := append([]string{cmdName, subCmdName}, args...)
 	err := command.Execute(cliArgs)
 	g.NoError(err)
+	g.Should(be.DeepEqual(gotArgs, args))
 }
 
 func TestSubCommandDuplicates(t *testing.T) {
@@ -829,3 +948,353 @@ func TestParseError(t *testing.T) {
 		})
 	}
 }
+
+func TestStringArg(t *testing.T) {
+	tests := []struct {
+		args    []string
+		options []ArgOption
+		want    string
+		err     string
+	}{
+		{
+			args: []string{"foo", "alice"},
+			want: "alice",
+		},
+		{
+			args: []string{"foo"},
+			err:  "missing required argument: <name>",
+		},
+		{
+			args:    []string{"foo"},
+			options: []ArgOption{ArgOptional},
+			want:    "world",
+		},
+		{
+			args:    []string{"foo", "--", "-alice"},
+			options: []ArgOption{ArgOptional},
+			want:    "-alice",
+		},
+		{
+			args:    []string{"foo", "Alice"},
+			options: []ArgOption{ArgValues([]string{"Alice", "Bruce", "Carl"})},
+			want:    "Alice",
+		},
+		{
+			args:    []string{"foo", "Alex"},
+			options: []ArgOption{ArgValues([]string{"Alice", "Bruce", "Carl"})},
+			err:     `argument "Alex" must be one of: Alice, Bruce, Carl`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			name := "world"
+			wasCalled := false
+			cmd := NewCommand(
+				"foo",
+				StringArg(&name, "name", tt.options...),
+				CommandAction(func(*Context) error {
+					wasCalled = true
+					return nil
+				}),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				g.Should(be.False(wasCalled))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.True(wasCalled))
+			g.Should(be.Equal(name, tt.want))
+		})
+	}
+}
+
+func TestStringArgOrder(t *testing.T) {
+	g := ghost.New(t)
+
+	defer func() {
+		g.Should(be.Equal(recover(), `required argument "b" cannot follow an optional argument`))
+	}()
+
+	NewCommand(
+		"foo",
+		StringArg(new(string), "a", ArgOptional),
+		StringArg(new(string), "b"),
+	)
+}
+
+func TestArgOptionConflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		option CommandOption
+		want   string
+	}{
+		{
+			name:   "min greater than max",
+			option: StringsArg(new([]string), "file", ArgMin(3), ArgMax(2)),
+			want:   `argument "file" cannot have a minimum of 3 greater than its maximum of 2`,
+		},
+		{
+			name:   "negative min",
+			option: StringsArg(new([]string), "file", ArgMin(-1)),
+			want:   `argument "file" cannot have a negative minimum or maximum`,
+		},
+		{
+			name:   "optional with min",
+			option: StringsArg(new([]string), "file", ArgOptional, ArgMin(2)),
+			want:   `argument "file" cannot be optional with a minimum of 2`,
+		},
+		{
+			name:   "min on string",
+			option: StringArg(new(string), "file", ArgMin(2)),
+			want:   `argument "file" cannot have a minimum or maximum unless it is variadic`,
+		},
+		{
+			name:   "max on int",
+			option: IntArg(new(int), "port", ArgMax(2)),
+			want:   `argument "port" cannot have a minimum or maximum unless it is variadic`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			defer func() {
+				g.Should(be.Equal(recover(), any(tt.want)))
+			}()
+
+			NewCommand("foo", tt.option)
+		})
+	}
+}
+
+func TestArgsWithSubCommands(t *testing.T) {
+	g := ghost.New(t)
+
+	defer func() {
+		g.Should(be.Equal(recover(), `command "foo" cannot have both arguments and sub-commands`))
+	}()
+
+	NewCommand(
+		"foo",
+		StringArg(new(string), "a"),
+		SubCommand("bar"),
+	)
+}
+
+func TestStringsArg(t *testing.T) {
+	tests := []struct {
+		args    []string
+		options []ArgOption
+		want    []string
+		rest    []string
+		err     string
+	}{
+		{
+			args: []string{"foo", "a"},
+			want: []string{"a"},
+		},
+		{
+			args: []string{"foo", "a", "b", "c"},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			args: []string{"foo"},
+			err:  "missing required argument: <file>...",
+		},
+		{
+			args:    []string{"foo"},
+			options: []ArgOption{ArgOptional},
+			want:    []string{"default"},
+		},
+		{
+			args:    []string{"foo", "a"},
+			options: []ArgOption{ArgMin(2)},
+			err:     "not enough arguments for <file>...: want at least 2, got 1",
+		},
+		{
+			args:    []string{"foo", "a", "b", "c"},
+			options: []ArgOption{ArgMax(2)},
+			want:    []string{"a", "b"},
+			rest:    []string{"c"},
+		},
+		{
+			args:    []string{"foo", "a", "z"},
+			options: []ArgOption{ArgValues([]string{"a", "b"})},
+			err:     `argument "z" must be one of: a, b`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			files := []string{"default"}
+			var rest []string
+			cmd := NewCommand(
+				"foo",
+				StringsArg(&files, "file", tt.options...),
+				CommandArgs(&rest),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.DeepEqual(files, tt.want))
+			g.Should(be.DeepEqual(rest, tt.rest))
+		})
+	}
+}
+
+func TestStringsArgExecuteTwice(t *testing.T) {
+	g := ghost.New(t)
+
+	var files []string
+	cmd := NewCommand(
+		"foo",
+		StringsArg(&files, "file"),
+		CommandAction(func(*Context) error { return nil }),
+	)
+
+	g.NoError(cmd.Execute([]string{"foo", "a"}))
+	g.Should(be.DeepEqual(files, []string{"a"}))
+
+	g.NoError(cmd.Execute([]string{"foo", "b"}))
+	g.Should(be.DeepEqual(files, []string{"b"}))
+}
+
+func TestTypedArgs(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var port int
+		var level slog.LevelVar
+		cmd := NewCommand(
+			"foo",
+			IntArg(&port, "port"),
+			TextVarArg(&level, "level"),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "8080", "WARN"}))
+		g.Should(be.Equal(port, 8080))
+		g.Should(be.Equal(level.Level(), slog.LevelWarn))
+	})
+
+	t.Run("invalid int", func(t *testing.T) {
+		g := ghost.New(t)
+
+		cmd := NewCommand(
+			"foo",
+			IntArg(new(int), "port"),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		err := cmd.Execute([]string{"foo", "http"})
+		g.Should(be.ErrorEqual(err, `invalid argument for <port>: not an integer: "http"`))
+		g.Should(be.Equal(exitCode(err), 2))
+	})
+}
+
+func TestStringsArgOrder(t *testing.T) {
+	g := ghost.New(t)
+
+	defer func() {
+		g.Should(be.Equal(recover(), `argument "b" cannot follow a variadic argument`))
+	}()
+
+	NewCommand(
+		"foo",
+		StringsArg(new([]string), "a"),
+		StringArg(new(string), "b", ArgOptional),
+	)
+}
+
+func TestCommandInterspersedFlags(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantVerbose bool
+		wantOut     string
+		wantArgs    []string
+		wantAfter   []string
+	}{
+		{
+			args:        []string{"foo", "build", "--verbose"},
+			wantVerbose: true,
+			wantArgs:    []string{"build"},
+		},
+		{
+			args:        []string{"foo", "a", "-v", "b", "--out", "dist", "c"},
+			wantVerbose: true,
+			wantOut:     "dist",
+			wantArgs:    []string{"a", "b", "c"},
+		},
+		{
+			args:      []string{"foo", "a", "--", "--verbose", "b"},
+			wantArgs:  []string{"a", "--verbose", "b"},
+			wantAfter: []string{"--verbose", "b"},
+		},
+		{
+			args:        []string{"foo", "-", "-v"},
+			wantVerbose: true,
+			wantArgs:    []string{"-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			var verbose bool
+			var out string
+			var args []string
+			cmd := NewCommand(
+				"foo",
+				CommandInterspersedFlags,
+				BoolFlag(&verbose, "verbose", FlagShort("v")),
+				StringFlag(&out, "out"),
+				CommandArgs(&args),
+				CommandAction(func(ctx *Context) error {
+					g.Should(be.DeepEqual(ctx.ArgsAfterDash(), tt.wantAfter))
+					return nil
+				}),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.Equal(verbose, tt.wantVerbose))
+			g.Should(be.Equal(out, tt.wantOut))
+			g.Should(be.DeepEqual(args, tt.wantArgs))
+		})
+	}
+
+	t.Run("sub-commands", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(
+				recover(),
+				`command "foo" cannot have both interspersed flags and sub-commands`,
+			))
+		}()
+
+		NewCommand(
+			"foo",
+			CommandInterspersedFlags,
+			SubCommand("bar"),
+		)
+	})
+}
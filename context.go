@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 )
 
 // Context is a command context with runtime metadata.
@@ -60,11 +61,89 @@ func (ctx *Context) Root() *Context {
 	return cur
 }
 
-// Args returns the list of arguments.
-func (ctx *Context) args() []string {
+// Args returns the list of positional arguments passed to the command.
+//
+// This includes any arguments passed after a "--" terminator. For commands
+// with sub-commands, the arguments begin with the name of the sub-command.
+func (ctx *Context) Args() []string {
 	return ctx.command.flagSet.Args()
 }
 
+// ArgsAfterDash returns the positional arguments passed after a "--"
+// terminator, or nil if no terminator was passed.
+//
+// The result is always a suffix of [Context.Args]. Unless the command uses
+// [CommandInterspersedFlags], a "--" passed after the first positional
+// argument is not a terminator, and is included as-is.
+func (ctx *Context) ArgsAfterDash() []string {
+	return ctx.command.flagSet.ArgsAfterDash()
+}
+
+// NArg returns the number of positional arguments passed to the command.
+func (ctx *Context) NArg() int {
+	return len(ctx.Args())
+}
+
+// FlagInfo is a read-only view of a flag and its current value.
+type FlagInfo struct {
+	// Name is the long name of the flag, without leading dashes.
+	Name string
+	// Short is the short name of the flag, if any.
+	Short string
+	// Value is the current value of the flag, formatted as a string.
+	Value string
+	// Env is the list of environment variables that can set the flag.
+	Env []string
+	// Changed is whether the flag was set on the command-line, by an
+	// environment variable, or by a value source.
+	Changed bool
+	// Source is where the flag's value came from.
+	Source FlagSource
+}
+
+// LookupFlag returns information about a flag by name.
+//
+// Flags can be looked up by name or alias, and include persistent flags
+// inherited from parent commands. Returns false if no such flag exists.
+func (ctx *Context) LookupFlag(name string) (FlagInfo, bool) {
+	f, ok := ctx.command.flagSet.lookup(name)
+	if !ok {
+		return FlagInfo{}, false
+	}
+
+	return FlagInfo{
+		Name:    f.name,
+		Short:   f.short,
+		Value:   f.value(),
+		Env:     slices.Clone(f.Env()),
+		Changed: f.changed,
+		Source:  f.source,
+	}, true
+}
+
+// FlagChanged returns whether a flag was set on the command-line, by an
+// environment variable, or by a value source, as opposed to being left at its
+// default value.
+//
+// Returns false if no such flag exists.
+func (ctx *Context) FlagChanged(name string) bool {
+	f, ok := ctx.command.flagSet.lookup(name)
+	return ok && f.changed
+}
+
+// FlagSource returns where the value of a flag came from.
+//
+// Flags that were not set, or do not exist, have a source of
+// [FlagSourceDefault].
+func (ctx *Context) FlagSource(name string) FlagSource {
+	f, ok := ctx.command.flagSet.lookup(name)
+	if !ok {
+		return FlagSource{}
+	}
+
+	return f.source
+}
+
 // run runs the command with a given context.
 func (ctx *Context) run(args []string) error {
 	if len(args) == 0 {
@@ -75,30 +154,126 @@ func (ctx *Context) run(args []string) error {
 		return newUsageError(ctx, err)
 	}
 
-	// Flag actions
-	if wasSet, err := ctx.command.flagAction(ctx); wasSet {
-		return err
+	isFinal := len(ctx.command.subCommandMap) == 0 || len(ctx.Args()) == 0
+	if err := ctx.command.flagSet.resolve(isFinal); err != nil {
+		return newUsageError(ctx, err)
+	}
+
+	for _, warning := range ctx.command.flagSet.Warnings() {
+		if err := ctx.deprecated(warning); err != nil {
+			return newUsageError(ctx, err)
+		}
+	}
+
+	// Flag actions, including persistent flags defined on a parent
+	for cur := ctx; cur != nil; cur = cur.parent {
+		if wasSet, err := cur.command.flagAction(ctx); wasSet {
+			return err
+		}
 	}
 
 	// No sub commands or command action
-	if len(ctx.command.subCommandMap) == 0 || len(ctx.args()) == 0 {
+	if isFinal {
+		if err := ctx.checkFlags(); err != nil {
+			return err
+		}
+
+		if err := ctx.parseArgs(); err != nil {
+			return newUsageError(ctx, err)
+		}
+
 		return ctx.command.action(ctx)
 	}
 
 	// Sub commands, something passed
-	subCmdName := ctx.args()[0]
+	subCmdName := ctx.Args()[0]
 	if subCmd, ok := ctx.command.subCommandMap[subCmdName]; ok {
 		subCtx := Context{
 			command: subCmd,
 			parent:  ctx,
 		}
 
-		return subCtx.run(ctx.args())
+		return subCtx.run(ctx.Args())
 	}
 
 	return newUsageError(ctx, fmt.Errorf("undefined sub-command: %s", subCmdName))
 }
 
+// deprecated reports the usage of a deprecated feature.
+//
+// An error is returned if strict deprecations are enabled.
+func (ctx *Context) deprecated(message string) error {
+	for cur := ctx; cur != nil; cur = cur.parent {
+		if cur.command.strictDeprecations {
+			return errors.New(message)
+		}
+	}
+
+	ctx.warn(message)
+	return nil
+}
+
+// warn reports a warning using the nearest warning handler.
+func (ctx *Context) warn(message string) {
+	for cur := ctx; cur != nil; cur = cur.parent {
+		if cur.command.warningHandler != nil {
+			cur.command.warningHandler(ctx, message)
+			return
+		}
+	}
+
+	fmt.Fprintf(ctx.Stderr(), "Warning: %s\n", message)
+}
+
+// checkFlags validates the flags of the command and each of its parents.
+//
+// Flags are checked only before running a command's action, so flag actions
+// such as --help work regardless of which flags were passed.
+func (ctx *Context) checkFlags() error {
+	if ctx.parent != nil {
+		if err := ctx.parent.checkFlags(); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.command.flagSet.checkRequired(); err != nil {
+		return newUsageError(ctx, err)
+	}
+
+	if err := ctx.command.flagSet.checkGroups(); err != nil {
+		return newUsageError(ctx, err)
+	}
+
+	if err := ctx.command.flagSet.validate(ctx); err != nil {
+		return newUsageError(ctx, err)
+	}
+
+	return nil
+}
+
+// parseArgs assigns the command's positional arguments.
+func (ctx *Context) parseArgs() error {
+	for _, a := range ctx.command.args {
+		a.changed = false
+	}
+
+	rest, err := parseArgs(ctx.command.args, ctx.Args())
+	if err != nil {
+		return err
+	}
+
+	if ctx.command.restArgs != nil {
+		*ctx.command.restArgs = rest
+		return nil
+	}
+
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected argument: %s", rest[0])
+	}
+
+	return nil
+}
+
 // printError prints an error with contextual information.
 func (ctx *Context) printError(err error) {
 	w := ctx.Stderr()
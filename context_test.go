@@ -31,7 +31,7 @@ func TestContextParent(t *testing.T) {
 	g.NoError(parent.Execute(args))
 	g.Should(be.True(wasCalled))
 	g.Should(be.Equal(pctx.Name(), parent.Name()))
-	g.Should(be.DeepEqual(pctx.args(), args[1:]))
+	g.Should(be.DeepEqual(pctx.Args(), args[1:]))
 }
 
 func TestContextParentNil(t *testing.T) {
@@ -115,3 +115,252 @@ func TestContextRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestContextArgs(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantArgs  []string
+		wantAfter []string
+	}{
+		{
+			args: []string{"foo"},
+		},
+		{
+			args:     []string{"foo", "a", "b"},
+			wantArgs: []string{"a", "b"},
+		},
+		{
+			args:      []string{"foo", "--", "a", "--b"},
+			wantArgs:  []string{"a", "--b"},
+			wantAfter: []string{"a", "--b"},
+		},
+		{
+			// Flag parsing ends at the first positional argument
+			args:     []string{"foo", "a", "--", "b"},
+			wantArgs: []string{"a", "--", "b"},
+		},
+		{
+			args:      []string{"foo", "--flag", "--", "a"},
+			wantArgs:  []string{"a"},
+			wantAfter: []string{"a"},
+		},
+		{
+			args:      []string{"foo", "--"},
+			wantAfter: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			wasCalled := false
+			cmd := NewCommand(
+				"foo",
+				ToggleFlag("flag"),
+				CommandArgs(new([]string)),
+				CommandAction(func(ctx *Context) error {
+					wasCalled = true
+					g.Should(be.DeepEqual(ctx.Args(), tt.wantArgs))
+					g.Should(be.DeepEqual(ctx.ArgsAfterDash(), tt.wantAfter))
+					g.Should(be.Equal(ctx.NArg(), len(tt.wantArgs)))
+					return nil
+				}),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.True(wasCalled))
+		})
+	}
+}
+
+func TestContextLookupFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		env     string
+		lookup  string
+		want    FlagInfo
+		wantOK  bool
+		changed bool
+	}{
+		{
+			args:   []string{"parent", "child"},
+			lookup: "name",
+			want: FlagInfo{
+				Name:  "name",
+				Short: "n",
+				Value: "default",
+				Env:   []string{"FLAG_NAME"},
+			},
+			wantOK: true,
+		},
+		{
+			args:   []string{"parent", "child", "--name", "custom"},
+			lookup: "name",
+			want: FlagInfo{
+				Name:    "name",
+				Short:   "n",
+				Value:   "custom",
+				Env:     []string{"FLAG_NAME"},
+				Changed: true,
+				Source:  FlagSource{Kind: FlagSourceCommandLine},
+			},
+			wantOK:  true,
+			changed: true,
+		},
+		{
+			args:   []string{"parent", "child"},
+			env:    "from-env",
+			lookup: "name",
+			want: FlagInfo{
+				Name:    "name",
+				Short:   "n",
+				Value:   "from-env",
+				Env:     []string{"FLAG_NAME"},
+				Changed: true,
+				Source:  FlagSource{Kind: FlagSourceEnv, Name: "FLAG_NAME"},
+			},
+			wantOK:  true,
+			changed: true,
+		},
+		{
+			args:   []string{"parent", "child", "--tag", "a", "--tag", "b"},
+			lookup: "tag",
+			want: FlagInfo{
+				Name:    "tag",
+				Value:   "a,b",
+				Changed: true,
+				Source:  FlagSource{Kind: FlagSourceCommandLine},
+			},
+			wantOK:  true,
+			changed: true,
+		},
+		{
+			args:   []string{"parent", "-v", "child"},
+			lookup: "verbose",
+			want: FlagInfo{
+				Name:    "verbose",
+				Short:   "v",
+				Value:   "true",
+				Changed: true,
+				Source:  FlagSource{Kind: FlagSourceCommandLine},
+			},
+			wantOK:  true,
+			changed: true,
+		},
+		{
+			args:   []string{"parent", "child"},
+			lookup: "local",
+		},
+		{
+			args:   []string{"parent", "child"},
+			lookup: "missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s", tt.args, tt.lookup), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_NAME", tt.env)
+			}
+
+			var (
+				verbose bool
+				local   bool
+				name    = "default"
+				tags    []string
+			)
+
+			wasCalled := false
+			cmd := NewCommand(
+				"parent",
+				BoolFlag(&verbose, "verbose", FlagShort("v"), FlagPersistent),
+				BoolFlag(&local, "local"),
+				SubCommand(
+					"child",
+					StringFlag(&name, "name", FlagShort("n"), FlagEnv("FLAG_NAME")),
+					StringsFlag(&tags, "tag"),
+					CommandAction(func(ctx *Context) error {
+						wasCalled = true
+
+						info, ok := ctx.LookupFlag(tt.lookup)
+						g.Should(be.Equal(ok, tt.wantOK))
+						g.Should(be.DeepEqual(info, tt.want))
+						g.Should(be.Equal(ctx.FlagChanged(tt.lookup), tt.changed))
+						return nil
+					}),
+				),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.True(wasCalled))
+		})
+	}
+}
+
+func TestContextFlagSource(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  map[string]string
+		want FlagSource
+	}{
+		{
+			args: []string{"foo"},
+			want: FlagSource{Kind: FlagSourceDefault},
+		},
+		{
+			args: []string{"foo", "--region", "us"},
+			env:  map[string]string{"FOO_REGION": "eu"},
+			want: FlagSource{Kind: FlagSourceCommandLine},
+		},
+		{
+			args: []string{"foo"},
+			env:  map[string]string{"REGION": "eu"},
+			want: FlagSource{Kind: FlagSourceEnv, Name: "REGION"},
+		},
+		{
+			args: []string{"foo"},
+			env:  map[string]string{"FOO_REGION": "eu", "REGION": "us"},
+			want: FlagSource{Kind: FlagSourceEnv, Name: "FOO_REGION"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %v", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			wasCalled := false
+			region := "staging"
+			cmd := NewCommand(
+				"foo",
+				StringFlag(&region, "region", FlagEnv("FOO_REGION", "REGION")),
+				CommandAction(func(ctx *Context) error {
+					wasCalled = true
+					g.Should(be.Equal(ctx.FlagSource("region"), tt.want))
+					g.Should(be.Equal(ctx.FlagSource("missing"), FlagSource{}))
+					return nil
+				}),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.True(wasCalled))
+		})
+	}
+}
+
+func TestFlagSourceString(t *testing.T) {
+	g := ghost.New(t)
+
+	g.Should(be.Equal(FlagSource{}.String(), "default"))
+	g.Should(be.Equal(FlagSource{Kind: FlagSourceCommandLine}.String(), "command line"))
+	g.Should(be.Equal(
+		FlagSource{Kind: FlagSourceEnv, Name: "REGION"}.String(),
+		"env var REGION",
+	))
+}
@@ -0,0 +1,173 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// loadEnvFiles reads variables from a list of env files.
+//
+// Missing files are skipped. If a variable is defined in more than one file,
+// the first file takes precedence.
+func loadEnvFiles(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	env := make(map[string]string)
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading env file: %w", err)
+		}
+
+		vars, err := parseDotenv(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("parsing env file %s: %w", path, err)
+		}
+
+		for k, v := range vars {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
+	}
+
+	return env, nil
+}
+
+// parseDotenv parses the contents of an env file.
+//
+// Each line is a KEY=VALUE pair, optionally preceded by "export". Blank lines
+// and lines beginning with "#" are ignored, as are comments following a value
+// after a space or tab. Values may be wrapped in single quotes, which are taken
+// literally, or double quotes, which support escape sequences such as "\n".
+// Quoted values may span multiple lines. Variables are not expanded.
+func parseDotenv(data string) (map[string]string, error) {
+	env := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+
+		line := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && startsWithSpace(rest) {
+			line = strings.TrimLeft(rest, " \t")
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf(`line %d: missing "="`, lineNum)
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key: %q", lineNum, key)
+		}
+
+		trimmed := strings.TrimLeft(value, " \t")
+		if trimmed == "" || (trimmed[0] != '"' && trimmed[0] != '\'') {
+			env[key] = strings.TrimSpace(cutComment(value))
+			continue
+		}
+		value = trimmed
+
+		quote := value[0]
+		end := closingQuote(value, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+			end = closingQuote(value, quote)
+		}
+
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated quoted value", lineNum)
+		}
+
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("line %d: unexpected characters after quoted value", lineNum)
+		}
+
+		value = value[1:end]
+		if quote == '"' {
+			value = unescapeDotenv(value)
+		}
+
+		env[key] = value
+	}
+
+	return env, nil
+}
+
+// startsWithSpace returns whether a string begins with a space or tab.
+func startsWithSpace(s string) bool {
+	return s != "" && (s[0] == ' ' || s[0] == '\t')
+}
+
+// cutComment removes a comment from an unquoted value.
+//
+// Comments begin with a "#" following a space or tab.
+func cutComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && startsWithSpace(s[i-1:]) {
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+// closingQuote returns the index of the quote closing a quoted value, or -1.
+//
+// Backslashes escape the following character in double-quoted values.
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// unescapeDotenv replaces escape sequences in a double-quoted value.
+//
+// Unknown escape sequences are left as-is.
+func unescapeDotenv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package clip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+		err  string
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: map[string]string{},
+		},
+		{
+			name: "basic",
+			data: "FOO=bar\nBAZ = qux \n",
+			want: map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# comment\n\n  # indented comment\nFOO=bar # trailing\nURL=a#b\n",
+			want: map[string]string{"FOO": "bar", "URL": "a#b"},
+		},
+		{
+			name: "export",
+			data: "export FOO=bar\nexport\tBAR=baz\nexport  BAZ=qux\nexporter=1\n",
+			want: map[string]string{"FOO": "bar", "BAR": "baz", "BAZ": "qux", "exporter": "1"},
+		},
+		{
+			name: "tab before comment",
+			data: "FOO=bar\t#comment\nBAR= # only a comment\nBAZ=#not-a-comment\n",
+			want: map[string]string{"FOO": "bar", "BAR": "", "BAZ": "#not-a-comment"},
+		},
+		{
+			name: "empty value",
+			data: "FOO=\nBAR=''\n",
+			want: map[string]string{"FOO": "", "BAR": ""},
+		},
+		{
+			name: "double quotes",
+			data: `FOO="a \"b\" # c\n\td\\e"  # comment`,
+			want: map[string]string{"FOO": "a \"b\" # c\n\td\\e"},
+		},
+		{
+			name: "single quotes",
+			data: `FOO='a \n "b"'`,
+			want: map[string]string{"FOO": `a \n "b"`},
+		},
+		{
+			name: "multi-line",
+			data: "KEY=\"-----BEGIN-----\nabc\n-----END-----\"\nFOO=bar\n",
+			want: map[string]string{"KEY": "-----BEGIN-----\nabc\n-----END-----", "FOO": "bar"},
+		},
+		{
+			name: "crlf",
+			data: "FOO=bar\r\nBAZ=qux\r\n",
+			want: map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name: "missing equals",
+			data: "FOO=bar\nBAZ\n",
+			err:  `line 2: missing "="`,
+		},
+		{
+			name: "invalid key",
+			data: "MY KEY=bar\n",
+			err:  `line 1: invalid key: "MY KEY"`,
+		},
+		{
+			name: "unterminated quote",
+			data: "FOO=bar\nBAZ=\"qux\nquux\n",
+			err:  "line 2: unterminated quoted value",
+		},
+		{
+			name: "characters after quote",
+			data: `FOO="bar"baz`,
+			err:  "line 1: unexpected characters after quoted value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			got, err := parseDotenv(tt.data)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.DeepEqual(got, tt.want))
+		})
+	}
+}
+
+func TestCommandEnvFile(t *testing.T) {
+	g := ghost.New(t)
+
+	dir := t.TempDir()
+	rootFile := filepath.Join(dir, "root.env")
+	childFile := filepath.Join(dir, "child.env")
+	rootEnv := "APP_NAME=root\nAPP_REGION=root\nAPP_LEVEL=root\n"
+	g.NoError(os.WriteFile(rootFile, []byte(rootEnv), 0o600))
+	g.NoError(os.WriteFile(childFile, []byte("APP_REGION=child\n"), 0o600))
+
+	t.Setenv("APP_LEVEL", "process")
+
+	var name, region, level string
+	wasCalled := false
+	cmd := NewCommand(
+		"app",
+		CommandEnvFile(filepath.Join(dir, "missing.env"), rootFile),
+		StringFlag(&name, "name", FlagEnv("APP_NAME"), FlagPersistent),
+		SubCommand(
+			"deploy",
+			CommandEnvFile(childFile),
+			StringFlag(&region, "region", FlagEnv("APP_REGION")),
+			StringFlag(&level, "level", FlagEnv("APP_LEVEL")),
+			CommandAction(func(ctx *Context) error {
+				wasCalled = true
+				g.Should(be.Equal(
+					ctx.FlagSource("region"),
+					FlagSource{Kind: FlagSourceEnv, Name: "APP_REGION"},
+				))
+				return nil
+			}),
+		),
+	)
+
+	g.NoError(cmd.Execute([]string{"app", "deploy"}))
+	g.Should(be.True(wasCalled))
+	g.Should(be.Equal(name, "root"))
+	g.Should(be.Equal(region, "child"))
+	g.Should(be.Equal(level, "process"))
+
+	_, ok := os.LookupEnv("APP_NAME")
+	g.Should(be.False(ok))
+}
+
+func TestCommandEnvFileError(t *testing.T) {
+	g := ghost.New(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	g.NoError(os.WriteFile(path, []byte("FOO\n"), 0o600))
+
+	cmd := NewCommand(
+		"app",
+		CommandEnvFile(path),
+		StringFlag(new(string), "foo", FlagEnv("FOO")),
+		CommandAction(func(*Context) error { return nil }),
+	)
+
+	err := cmd.Execute([]string{"app"})
+	g.Should(be.ErrorEqual(err, "parsing env file "+path+`: line 1: missing "="`))
+	g.Should(be.Equal(exitCode(err), 2))
+}
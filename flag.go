@@ -2,19 +2,36 @@ package clip
 
 import (
 	"cmp"
+	"encoding"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // flagDef is a command-line flag.
 //
 // Methods are defined for use in help text.
 type flagDef struct {
-	name  string
-	short string
-
-	action  func(*Context) error
-	boolVal string
-	env     []string
+	name              string
+	short             string
+	aliases           []string
+	deprecatedAliases []string
+
+	action        func(*Context) error
+	boolVal       string
+	boolean       bool
+	env           []string
+	negatable     bool
+	optionalValue bool
+	persistent    bool
+	repeatable    bool
+	required      bool
+	sensitive     bool
+	separator     string
+	validators    []func(*Context) error
 
 	description string
 	deprecated  string
@@ -22,9 +39,14 @@ type flagDef struct {
 	helpDefault string
 	hideDefault bool
 	placeholder string
+	choices     []string
 
+	owner   *flagSet
 	setFunc func(string) error
+	incFunc func()
+	getFunc func() string
 	changed bool
+	source  FlagSource
 }
 
 // Usage returns padded usage text for use in help docs.
@@ -34,19 +56,38 @@ func (f *flagDef) Usage() string {
 		usage = "  -" + f.short + ", "
 	}
 
-	usage += "--" + f.name
+	if f.negatable {
+		usage += "--[no-]" + f.name
+	} else {
+		usage += "--" + f.name
+	}
 
-	if f.placeholder != "" {
-		sep := " "
-		if f.boolVal != "" {
-			sep = "="
-		}
-		usage += sep + f.placeholder
+	switch {
+	case f.placeholder == "":
+	case f.optionalValue:
+		usage += "[=" + f.placeholder + "]"
+	case f.boolVal != "" || f.incFunc != nil:
+		usage += "=" + f.placeholder
+	default:
+		usage += " " + f.placeholder
 	}
 
 	return usage
 }
 
+// Aliases returns the list of alternate names for the flag.
+func (f *flagDef) Aliases() []string {
+	aliases := make([]string, 0, len(f.aliases)+len(f.deprecatedAliases))
+	for _, alias := range f.aliases {
+		aliases = append(aliases, "--"+alias)
+	}
+	for _, alias := range f.deprecatedAliases {
+		aliases = append(aliases, "--"+alias+" (deprecated)")
+	}
+
+	return aliases
+}
+
 // Description returns a description of the flag.
 func (f *flagDef) Description() string { return f.description }
 
@@ -54,7 +95,36 @@ func (f *flagDef) Description() string { return f.description }
 func (f *flagDef) Deprecated() string { return f.deprecated }
 
 // Env returns the list of environment variables.
-func (f *flagDef) Env() []string { return f.env }
+//
+// If no environment variables were set for the flag, the name is derived from
+// the command's env prefix, if any. Flags with an action are never derived.
+func (f *flagDef) Env() []string {
+	if f.env != nil || f.action != nil || f.owner == nil {
+		return f.env
+	}
+
+	if env := f.owner.derivedEnv(f.name); env != "" {
+		return []string{env}
+	}
+
+	return nil
+}
+
+// Config returns the key used to look up the flag in a value source, if the
+// command has one.
+func (f *flagDef) Config() string {
+	if f.owner == nil || f.owner.lookupValueSource() == nil || f.action != nil {
+		return ""
+	}
+
+	return f.owner.configKey(f.name)
+}
+
+// Required returns whether the flag must be set.
+func (f *flagDef) Required() bool { return f.required }
+
+// Choices returns the list of allowed values, if any.
+func (f *flagDef) Choices() []string { return f.choices }
 
 // Default returns the default value of a flag.
 func (f *flagDef) Default() string {
@@ -68,6 +138,26 @@ func (f *flagDef) Default() string {
 // Hidden returns whether a flag should be hidden from help and tab completion.
 func (f *flagDef) Hidden() bool { return f.hidden }
 
+// negatedName returns the name used to set a negatable flag to false.
+func (f *flagDef) negatedName() string { return "no-" + f.name }
+
+// split splits a value into multiple values using the flag's separator.
+func (f *flagDef) split(v string) []string {
+	if f.separator == "" {
+		return []string{v}
+	}
+	return strings.Split(v, f.separator)
+}
+
+// value returns the flag's current value as a string.
+func (f *flagDef) value() string { return f.getFunc() }
+
+// increment increments a count flag passed without a value.
+func (f *flagDef) increment() {
+	f.incFunc()
+	f.changed = true
+}
+
 // set assigns a string value to a flag.
 func (f *flagDef) set(v string) error {
 	if err := f.setFunc(v); err != nil {
@@ -83,7 +173,7 @@ func (f *flagDef) set(v string) error {
 // to toggle something on. This is the simplest way to create an action flag.
 func ToggleFlag(name string, options ...FlagOption) CommandOption {
 	return func(c *commandConfig) {
-		f := newFlag(name, options...)
+		f := newValuelessFlag(name, options...)
 		f.boolVal = "true"
 		f.setFunc = func(s string) error {
 			switch s {
@@ -93,6 +183,7 @@ func ToggleFlag(name string, options ...FlagOption) CommandOption {
 				return fmt.Errorf("invalid toggle flag option: %s", s)
 			}
 		}
+		f.getFunc = func() string { return strconv.FormatBool(f.changed) }
 
 		c.addFlag(f)
 	}
@@ -101,10 +192,13 @@ func ToggleFlag(name string, options ...FlagOption) CommandOption {
 // BoolFlag creates a new boolean flag.
 func BoolFlag(value *bool, name string, options ...FlagOption) CommandOption {
 	return func(c *commandConfig) {
-		f := newFlag(name, options...)
+		f := newValuelessFlag(name, options...)
 		f.boolVal = "true"
+		f.boolean = true
 		if *value {
-			f.placeholder = cmp.Or(f.placeholder, "<bool>")
+			if !f.negatable {
+				f.placeholder = cmp.Or(f.placeholder, "<bool>")
+			}
 			f.helpDefault = cmp.Or(f.helpDefault, "true")
 		}
 
@@ -120,6 +214,44 @@ func BoolFlag(value *bool, name string, options ...FlagOption) CommandOption {
 
 			return nil
 		}
+		f.getFunc = func() string { return strconv.FormatBool(*value) }
+
+		c.addFlag(f)
+	}
+}
+
+// CountFlag creates a new counter flag.
+//
+// Each occurrence of the flag increments the counter, so -vvv sets a value of
+// three. A value can also be set directly, such as with --verbose=3 or using
+// an environment variable. The counter starts from its default value each time
+// the command is run.
+func CountFlag(value *int, name string, options ...FlagOption) CommandOption {
+	return func(c *commandConfig) {
+		f := newValuelessFlag(name, options...)
+		if *value != 0 {
+			f.placeholder = cmp.Or(f.placeholder, "<int>")
+			f.helpDefault = cmp.Or(f.helpDefault, strconv.Itoa(*value))
+		}
+
+		initial := *value
+		f.incFunc = func() {
+			if !f.changed {
+				*value = initial
+			}
+			*value++
+		}
+
+		f.setFunc = func(s string) error {
+			n, err := parseInt(s, 0)
+			if err != nil {
+				return err
+			}
+
+			*value = int(n)
+			return nil
+		}
+		f.getFunc = func() string { return strconv.Itoa(*value) }
 
 		c.addFlag(f)
 	}
@@ -127,17 +259,230 @@ func BoolFlag(value *bool, name string, options ...FlagOption) CommandOption {
 
 // StringFlag creates a new string flag.
 func StringFlag(value *string, name string, options ...FlagOption) CommandOption {
+	parse := func(s string) (string, error) { return s, nil }
+	format := func(s string) string { return s }
+	return valueFlag(value, name, "<string>", parse, format, options...)
+}
+
+// IntFlag creates a new integer flag.
+func IntFlag(value *int, name string, options ...FlagOption) CommandOption {
+	parse := func(s string) (int, error) {
+		n, err := parseInt(s, 0)
+		return int(n), err
+	}
+	return valueFlag(value, name, "<int>", parse, strconv.Itoa, options...)
+}
+
+// Int64Flag creates a new 64-bit integer flag.
+func Int64Flag(value *int64, name string, options ...FlagOption) CommandOption {
+	parse := func(s string) (int64, error) { return parseInt(s, 64) }
+	format := func(n int64) string { return strconv.FormatInt(n, 10) }
+	return valueFlag(value, name, "<int>", parse, format, options...)
+}
+
+// UintFlag creates a new unsigned integer flag.
+func UintFlag(value *uint, name string, options ...FlagOption) CommandOption {
+	parse := func(s string) (uint, error) {
+		n, err := parseUint(s, 0)
+		return uint(n), err
+	}
+	format := func(n uint) string { return strconv.FormatUint(uint64(n), 10) }
+	return valueFlag(value, name, "<uint>", parse, format, options...)
+}
+
+// Float64Flag creates a new floating-point flag.
+func Float64Flag(value *float64, name string, options ...FlagOption) CommandOption {
+	format := func(n float64) string { return strconv.FormatFloat(n, 'g', -1, 64) }
+	return valueFlag(value, name, "<float>", parseFloat, format, options...)
+}
+
+// DurationFlag creates a new duration flag.
+//
+// Values are parsed using [time.ParseDuration].
+func DurationFlag(value *time.Duration, name string, options ...FlagOption) CommandOption {
+	return valueFlag(value, name, "<duration>", parseDuration, time.Duration.String, options...)
+}
+
+// TimeFlag creates a new time flag.
+//
+// Values are parsed and displayed using the layout, as in [time.Parse].
+func TimeFlag(
+	value *time.Time,
+	layout string,
+	name string,
+	options ...FlagOption,
+) CommandOption {
+	parse := func(s string) (time.Time, error) { return parseTime(layout, s) }
+	format := func(t time.Time) string { return t.Format(layout) }
+	return valueFlag(value, name, "<time>", parse, format, options...)
+}
+
+// StringsFlag creates a new repeatable string flag.
+//
+// Each occurrence of the flag appends to the slice. Any default values are
+// replaced the first time the flag is set. Values from environment variables
+// are split on the [FlagSeparator], or a comma by default, and empty
+// environment variables are treated as unset.
+func StringsFlag(value *[]string, name string, options ...FlagOption) CommandOption {
+	return func(c *commandConfig) {
+		f := newFlag(name, options...)
+		f.repeatable = true
+		f.placeholder = cmp.Or(f.placeholder, "<string>")
+		if len(*value) > 0 {
+			f.helpDefault = cmp.Or(f.helpDefault, strings.Join(*value, ", "))
+		}
+
+		f.setFunc = func(s string) error {
+			if !f.changed {
+				*value = nil
+			}
+			*value = append(*value, f.split(s)...)
+			return nil
+		}
+		f.getFunc = func() string {
+			return strings.Join(*value, cmp.Or(f.separator, ","))
+		}
+
+		c.addFlag(f)
+	}
+}
+
+// IntsFlag creates a new repeatable integer flag.
+//
+// Each occurrence of the flag appends to the slice. Any default values are
+// replaced the first time the flag is set. Values from environment variables
+// are split on the [FlagSeparator], or a comma by default, and empty
+// environment variables are treated as unset.
+func IntsFlag(value *[]int, name string, options ...FlagOption) CommandOption {
+	return func(c *commandConfig) {
+		f := newFlag(name, options...)
+		f.repeatable = true
+		f.placeholder = cmp.Or(f.placeholder, "<int>")
+		if len(*value) > 0 {
+			defaults := make([]string, 0, len(*value))
+			for _, n := range *value {
+				defaults = append(defaults, strconv.Itoa(n))
+			}
+			f.helpDefault = cmp.Or(f.helpDefault, strings.Join(defaults, ", "))
+		}
+
+		f.setFunc = func(s string) error {
+			values := f.split(s)
+			ns := make([]int, 0, len(values))
+			for _, v := range values {
+				n, err := parseInt(v, 0)
+				if err != nil {
+					return err
+				}
+				ns = append(ns, int(n))
+			}
+
+			if !f.changed {
+				*value = nil
+			}
+			*value = append(*value, ns...)
+			return nil
+		}
+		f.getFunc = func() string {
+			values := make([]string, 0, len(*value))
+			for _, n := range *value {
+				values = append(values, strconv.Itoa(n))
+			}
+			return strings.Join(values, cmp.Or(f.separator, ","))
+		}
+
+		c.addFlag(f)
+	}
+}
+
+// StringMapFlag creates a new repeatable key/value flag.
+//
+// Values are passed as key=value pairs, and multiple pairs can be passed at
+// once separated by the [FlagSeparator], or a comma by default. Each
+// occurrence of the flag adds to the map. Any default entries are replaced
+// the first time the flag is set. Empty environment variables are treated as
+// unset.
+func StringMapFlag(value *map[string]string, name string, options ...FlagOption) CommandOption {
+	return func(c *commandConfig) {
+		f := newFlag(name, options...)
+		f.repeatable = true
+		f.separator = cmp.Or(f.separator, ",")
+		f.placeholder = cmp.Or(f.placeholder, "<key=value>")
+		if len(*value) > 0 {
+			defaults := make([]string, 0, len(*value))
+			for _, k := range slices.Sorted(maps.Keys(*value)) {
+				defaults = append(defaults, k+"="+(*value)[k])
+			}
+			f.helpDefault = cmp.Or(f.helpDefault, strings.Join(defaults, ", "))
+		}
+
+		f.setFunc = func(s string) error {
+			entries := make(map[string]string)
+			for _, entry := range f.split(s) {
+				k, v, ok := strings.Cut(entry, "=")
+				switch {
+				case !ok:
+					return fmt.Errorf(`missing "=" in entry: %q`, entry)
+				case k == "":
+					return fmt.Errorf("missing key in entry: %q", entry)
+				}
+				entries[k] = v
+			}
+
+			if !f.changed || *value == nil {
+				*value = make(map[string]string, len(entries))
+			}
+			maps.Copy(*value, entries)
+			return nil
+		}
+		f.getFunc = func() string {
+			entries := make([]string, 0, len(*value))
+			for _, k := range slices.Sorted(maps.Keys(*value)) {
+				entries = append(entries, k+"="+(*value)[k])
+			}
+			return strings.Join(entries, f.separator)
+		}
+
+		c.addFlag(f)
+	}
+}
+
+// EnumFlag creates a new flag restricted to a list of allowed values.
+//
+// The value can be a string or any type with an underlying string type, which
+// allows the choices to be a list of typed constants. Panics if the default
+// value is not empty or one of the choices.
+func EnumFlag[T ~string](
+	value *T,
+	name string,
+	choices []T,
+	options ...FlagOption,
+) CommandOption {
 	return func(c *commandConfig) {
 		f := newFlag(name, options...)
 		f.placeholder = cmp.Or(f.placeholder, "<string>")
 		if *value != "" {
-			f.helpDefault = cmp.Or(f.helpDefault, *value)
+			f.helpDefault = cmp.Or(f.helpDefault, string(*value))
+		}
+
+		f.choices = make([]string, 0, len(choices))
+		for _, choice := range choices {
+			f.choices = append(f.choices, string(choice))
+		}
+
+		if *value != "" && !slices.Contains(f.choices, string(*value)) {
+			panic(fmt.Sprintf("flag %q has a default value that is not a choice: %q", name, *value))
 		}
 
 		f.setFunc = func(s string) error {
-			*value = s
+			if err := checkChoice(s, f.choices); err != nil {
+				return err
+			}
+
+			*value = T(s)
 			return nil
 		}
+		f.getFunc = func() string { return string(*value) }
 
 		c.addFlag(f)
 	}
@@ -146,25 +491,98 @@ func StringFlag(value *string, name string, options ...FlagOption) CommandOption
 // TextVarFlag creates a new flag based on [encoding.TextMarshaler] and
 // [encoding.TextUnmarshaler].
 func TextVarFlag(value TextVar, name string, options ...FlagOption) CommandOption {
+	parse := func(s string) (TextVar, error) {
+		return value, value.UnmarshalText([]byte(s))
+	}
+	return Flag(&value, name, parse, nil, options...)
+}
+
+// Flag creates a new flag of any type.
+//
+// The parse function converts a command-line value to the flag's type. The
+// format function is used to display non-zero default values in help text,
+// and can be nil to never display a default. It is also used to report the
+// flag's value with [Context.LookupFlag].
+//
+// To reuse the same functions across many flags, see [RegisterFlagType].
+func Flag[T any](
+	value *T,
+	name string,
+	parse func(string) (T, error),
+	format func(T) string,
+	options ...FlagOption,
+) CommandOption {
+	return valueFlag(value, name, "<value>", parse, format, options...)
+}
+
+// valueFlag creates a new single-value flag with a default placeholder.
+func valueFlag[T any](
+	value *T,
+	name string,
+	placeholder string,
+	parse func(string) (T, error),
+	format func(T) string,
+	options ...FlagOption,
+) CommandOption {
 	return func(c *commandConfig) {
 		f := newFlag(name, options...)
-		f.placeholder = cmp.Or(f.placeholder, "<value>")
+		f.placeholder = cmp.Or(f.placeholder, placeholder)
+		if format != nil {
+			var zero T
+			if s := format(*value); s != format(zero) {
+				f.helpDefault = cmp.Or(f.helpDefault, s)
+			}
+		}
+
 		f.setFunc = func(s string) error {
-			return value.UnmarshalText([]byte(s))
+			v, err := parse(s)
+			if err != nil {
+				return err
+			}
+
+			*value = v
+			return nil
+		}
+		f.getFunc = func() string {
+			if format != nil {
+				return format(*value)
+			}
+			return formatAny(*value)
 		}
 
 		c.addFlag(f)
 	}
 }
 
+// formatAny formats a value without a known format function.
+func formatAny(v any) string {
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	return fmt.Sprint(v)
+}
+
 // FlagOption is an option for creating a Flag.
 type FlagOption func(*flagConfig)
 
 type flagConfig struct {
-	short string
-
-	action func(*Context) error
-	env    []string
+	short             string
+	aliases           []string
+	deprecatedAliases []string
+
+	action           func(*Context) error
+	env              []string
+	negatable        bool
+	hasOptionalValue bool
+	optionalValue    string
+	persistent       bool
+	required         bool
+	sensitive        bool
+	separator        string
+	validators       []func(*Context) error
 
 	description string
 	deprecated  string
@@ -181,11 +599,21 @@ func newFlag(name string, options ...FlagOption) *flagDef {
 	}
 
 	return &flagDef{
-		name:  name,
-		short: c.short,
-
-		action: c.action,
-		env:    c.env,
+		name:              name,
+		short:             c.short,
+		aliases:           c.aliases,
+		deprecatedAliases: c.deprecatedAliases,
+
+		action:        c.action,
+		boolVal:       c.optionalValue,
+		env:           c.env,
+		negatable:     c.negatable,
+		optionalValue: c.hasOptionalValue,
+		persistent:    c.persistent,
+		required:      c.required,
+		sensitive:     c.sensitive,
+		separator:     c.separator,
+		validators:    c.validators,
 
 		description: c.description,
 		deprecated:  c.deprecated,
@@ -196,11 +624,80 @@ func newFlag(name string, options ...FlagOption) *flagDef {
 	}
 }
 
+// newValuelessFlag creates a flag that can always be passed without a value.
+// Panics if the flag has an optional value, which would have no effect.
+func newValuelessFlag(name string, options ...FlagOption) *flagDef {
+	f := newFlag(name, options...)
+	if f.optionalValue {
+		panic(fmt.Sprintf(
+			"flag %q cannot have an optional value since it never requires a value", name,
+		))
+	}
+
+	return f
+}
+
 // FlagHidden prevents the flag from being shown.
 func FlagHidden(c *flagConfig) {
 	c.hidden = true
 }
 
+// FlagNegatable adds a negated name to a boolean flag.
+// Panics if used on a flag other than a [BoolFlag].
+//
+// For a flag named "cache", passing --no-cache sets the flag to false. This
+// is most useful for flags with a default value of true.
+func FlagNegatable(c *flagConfig) {
+	c.negatable = true
+}
+
+// FlagOptionalValue allows a flag to be passed without a value.
+// Panics if used on a flag that never requires a value, such as a [BoolFlag].
+//
+// When the flag is passed without a value, the value passed to this function
+// is used instead. A value can still be passed using an equals sign, such as
+// --color=never, but the argument after the flag is never consumed.
+func FlagOptionalValue(value string) FlagOption {
+	return func(c *flagConfig) {
+		c.hasOptionalValue = true
+		c.optionalValue = value
+	}
+}
+
+// FlagPersistent makes a flag available to every sub-command.
+//
+// A persistent flag can be passed after the name of any sub-command, at any
+// depth, unless the sub-command defines a flag with the same name.
+func FlagPersistent(c *flagConfig) {
+	c.persistent = true
+}
+
+// FlagRequired requires a flag to be set, either on the command-line, by an
+// environment variable, or by a value source.
+func FlagRequired(c *flagConfig) {
+	c.required = true
+}
+
+// FlagSensitive marks a flag's value as sensitive, such as a password or
+// token.
+//
+// The values of sensitive flags are masked in output meant for diagnostics,
+// such as the output of [CommandDebugFlags].
+func FlagSensitive(c *flagConfig) {
+	c.sensitive = true
+}
+
+// FlagValidate adds a validation function to a flag.
+//
+// Validation functions run after all flags have been parsed, including from
+// environment variables, but only if the flag was set. Successive calls will
+// add to earlier values.
+func FlagValidate(validate func(ctx *Context) error) FlagOption {
+	return func(c *flagConfig) {
+		c.validators = append(c.validators, validate)
+	}
+}
+
 // FlagShort adds a short name to a flag.
 // Panics if the name is not exactly one ASCII character.
 func FlagShort(name string) FlagOption {
@@ -209,6 +706,26 @@ func FlagShort(name string) FlagOption {
 	}
 }
 
+// FlagAlias adds alternate long names for a flag.
+//
+// Successive calls will add to earlier values.
+func FlagAlias(names ...string) FlagOption {
+	return func(c *flagConfig) {
+		c.aliases = append(c.aliases, names...)
+	}
+}
+
+// FlagDeprecatedAlias adds deprecated alternate long names for a flag.
+//
+// Using a deprecated alias prints a warning suggesting the flag's name
+// instead. This is useful for renaming a flag without breaking existing usage.
+// Successive calls will add to earlier values.
+func FlagDeprecatedAlias(names ...string) FlagOption {
+	return func(c *flagConfig) {
+		c.deprecatedAliases = append(c.deprecatedAliases, names...)
+	}
+}
+
 // FlagDescription adds a description to a flag.
 //
 // Descriptions can span multiple lines.
@@ -227,10 +744,25 @@ func FlagDeprecated(derepcation string) FlagOption {
 
 // FlagEnv sets the list of environment variables for a flag.
 //
-// Successive calls will replace earlier values.
+// This replaces any name derived from [CommandEnvPrefix]. To prevent a flag
+// from being set by an environment variable, pass no names. Successive calls
+// will replace earlier values.
 func FlagEnv(env ...string) FlagOption {
 	return func(c *flagConfig) {
-		c.env = env
+		// A non-nil empty list disables derived names
+		c.env = append([]string{}, env...)
+	}
+}
+
+// FlagSeparator sets a separator for passing multiple values at once.
+//
+// This applies only to flags that accept multiple values, such as
+// [StringsFlag]. Each value passed on the command-line is split on the
+// separator, and values from environment variables are split on the separator
+// instead of a comma.
+func FlagSeparator(sep string) FlagOption {
+	return func(c *flagConfig) {
+		c.separator = sep
 	}
 }
 
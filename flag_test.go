@@ -0,0 +1,1921 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestNumericFlags(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var (
+			i   int
+			i64 int64
+			u   uint
+			f64 float64
+		)
+
+		t.Setenv("FLAG_FLOAT", "2.5")
+
+		cmd := NewCommand(
+			"foo",
+			IntFlag(&i, "int", FlagShort("i")),
+			Int64Flag(&i64, "int64"),
+			UintFlag(&u, "uint"),
+			Float64Flag(&f64, "float", FlagEnv("FLAG_FLOAT")),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{
+			"foo",
+			"-i", "-3",
+			"--int64=9000000000",
+			"--uint", "7",
+		}))
+
+		g.Should(be.Equal(i, -3))
+		g.Should(be.Equal(i64, 9000000000))
+		g.Should(be.Equal(u, 7))
+		g.Should(be.Equal(f64, 2.5))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			args []string
+			err  string
+		}{
+			{
+				args: []string{"foo", "--retries", "x"},
+				err:  `invalid argument for flag --retries: not an integer: "x"`,
+			},
+			{
+				args: []string{"foo", "--retries", "1.5"},
+				err:  `invalid argument for flag --retries: not an integer: "1.5"`,
+			},
+			{
+				args: []string{"foo", "--big", "99999999999999999999"},
+				err:  `invalid argument for flag --big: integer out of range: "99999999999999999999"`,
+			},
+			{
+				args: []string{"foo", "--count", "-1"},
+				err:  `invalid argument for flag --count: not an unsigned integer: "-1"`,
+			},
+			{
+				args: []string{"foo", "--ratio", "half"},
+				err:  `invalid argument for flag --ratio: not a number: "half"`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+				g := ghost.New(t)
+
+				cmd := NewCommand(
+					"foo",
+					IntFlag(new(int), "retries"),
+					Int64Flag(new(int64), "big"),
+					UintFlag(new(uint), "count"),
+					Float64Flag(new(float64), "ratio"),
+					CommandAction(func(*Context) error { return nil }),
+				)
+
+				err := cmd.Execute(tt.args)
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+			})
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		retries := 3
+		var timeout int64
+		workers := uint(4)
+		ratio := 0.75
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			IntFlag(&retries, "retries"),
+			Int64Flag(&timeout, "timeout"),
+			UintFlag(&workers, "workers"),
+			Float64Flag(&ratio, "ratio"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+
+		output := buf.String()
+		g.Should(be.StringContaining(output, "--retries <int>\n          Default: 3\n"))
+		g.Should(be.StringContaining(output, "--timeout <int>\n\n"))
+		g.Should(be.StringContaining(output, "--workers <uint>\n          Default: 4\n"))
+		g.Should(be.StringContaining(output, "--ratio <float>\n          Default: 0.75\n"))
+	})
+}
+
+func TestTimeFlags(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var timeout time.Duration
+		var since time.Time
+
+		t.Setenv("FLAG_SINCE", "2024-02-29")
+
+		cmd := NewCommand(
+			"foo",
+			DurationFlag(&timeout, "timeout"),
+			TimeFlag(&since, time.DateOnly, "since", FlagEnv("FLAG_SINCE")),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "--timeout", "1m30s"}))
+		g.Should(be.Equal(timeout, 90*time.Second))
+		g.Should(be.Equal(since, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			args []string
+			err  string
+		}{
+			{
+				args: []string{"foo", "--timeout", "10"},
+				err:  `invalid argument for flag --timeout: not a duration: "10"`,
+			},
+			{
+				args: []string{"foo", "-s", "yesterday"},
+				err: `invalid argument for flag 's' in -s: ` +
+					`not a time matching "2006-01-02": "yesterday"`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+				g := ghost.New(t)
+
+				cmd := NewCommand(
+					"foo",
+					DurationFlag(new(time.Duration), "timeout"),
+					TimeFlag(new(time.Time), time.DateOnly, "since", FlagShort("s")),
+					CommandAction(func(*Context) error { return nil }),
+				)
+
+				err := cmd.Execute(tt.args)
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+			})
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		timeout := 5 * time.Minute
+		since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			DurationFlag(&timeout, "timeout"),
+			TimeFlag(&since, time.DateOnly, "since"),
+			TimeFlag(new(time.Time), time.DateOnly, "until"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+
+		output := buf.String()
+		g.Should(be.StringContaining(output, "--timeout <duration>\n          Default: 5m0s\n"))
+		g.Should(be.StringContaining(output, "--since <time>\n          Default: 2024-01-02\n"))
+		g.Should(be.StringContaining(output, "--until <time>\n"))
+	})
+}
+
+func TestSliceFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		options  []FlagOption
+		wantTags []string
+		wantIDs  []int
+	}{
+		{
+			name:     "defaults",
+			args:     []string{"foo"},
+			wantTags: []string{"default"},
+			wantIDs:  []int{1},
+		},
+		{
+			name:     "repeated",
+			args:     []string{"foo", "--tag", "a", "-t", "b", "--id=2", "--id", "3"},
+			wantTags: []string{"a", "b"},
+			wantIDs:  []int{2, 3},
+		},
+		{
+			name:     "no split by default",
+			args:     []string{"foo", "--tag", "a,b"},
+			wantTags: []string{"a,b"},
+			wantIDs:  []int{1},
+		},
+		{
+			name:     "split with separator",
+			args:     []string{"foo", "--tag", "a,b", "--tag", "c", "--id", "4,5"},
+			options:  []FlagOption{FlagSeparator(",")},
+			wantTags: []string{"a", "b", "c"},
+			wantIDs:  []int{4, 5},
+		},
+		{
+			name:     "env",
+			args:     []string{"foo"},
+			env:      "x,y",
+			wantTags: []string{"x", "y"},
+			wantIDs:  []int{1},
+		},
+		{
+			name:     "env with separator",
+			args:     []string{"foo"},
+			env:      "x:y,z",
+			options:  []FlagOption{FlagSeparator(":")},
+			wantTags: []string{"x", "y,z"},
+			wantIDs:  []int{1},
+		},
+		{
+			name:     "cli overrides env",
+			args:     []string{"foo", "--tag", "a"},
+			env:      "x,y",
+			wantTags: []string{"a"},
+			wantIDs:  []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_TAGS", tt.env)
+			}
+
+			tags := []string{"default"}
+			ids := []int{1}
+			cmd := NewCommand(
+				"foo",
+				StringsFlag(
+					&tags,
+					"tag",
+					append(tt.options, FlagShort("t"), FlagEnv("FLAG_TAGS"))...,
+				),
+				IntsFlag(&ids, "id", tt.options...),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.DeepEqual(tags, tt.wantTags))
+			g.Should(be.DeepEqual(ids, tt.wantIDs))
+		})
+	}
+
+	t.Run("invalid int", func(t *testing.T) {
+		g := ghost.New(t)
+
+		cmd := NewCommand(
+			"foo",
+			IntsFlag(new([]int), "id", FlagSeparator(",")),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		err := cmd.Execute([]string{"foo", "--id", "1,x"})
+		g.Should(be.ErrorEqual(err, `invalid argument for flag --id: not an integer: "x"`))
+	})
+
+	t.Run("empty env", func(t *testing.T) {
+		g := ghost.New(t)
+
+		t.Setenv("FLAG_TAGS", "")
+		t.Setenv("FLAG_IDS", "")
+		t.Setenv("FLAG_IDS_FALLBACK", "7")
+
+		tags := []string{"default"}
+		ids := []int{1}
+		cmd := NewCommand(
+			"foo",
+			StringsFlag(&tags, "tag", FlagEnv("FLAG_TAGS")),
+			IntsFlag(&ids, "id", FlagEnv("FLAG_IDS", "FLAG_IDS_FALLBACK")),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+		g.Should(be.DeepEqual(tags, []string{"default"}))
+		g.Should(be.DeepEqual(ids, []int{7}))
+	})
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		tags := []string{"a", "b"}
+		ids := []int{1, 2}
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			StringsFlag(&tags, "tag"),
+			IntsFlag(&ids, "id"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+
+		output := buf.String()
+		g.Should(be.StringContaining(output, "--tag <string>\n          Default: a, b\n"))
+		g.Should(be.StringContaining(output, "--id <int>\n          Default: 1, 2\n"))
+	})
+}
+
+func TestStringMapFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		options []FlagOption
+		want    map[string]string
+		err     string
+	}{
+		{
+			name: "defaults",
+			args: []string{"foo"},
+			want: map[string]string{"env": "dev"},
+		},
+		{
+			name: "repeated",
+			args: []string{"foo", "--label", "env=prod", "--label", "team=core"},
+			want: map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			name: "comma separated",
+			args: []string{"foo", "--label", "env=prod,team=core"},
+			want: map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			name:    "custom separator",
+			args:    []string{"foo", "--label", "env=prod;team=a,b"},
+			options: []FlagOption{FlagSeparator(";")},
+			want:    map[string]string{"env": "prod", "team": "a,b"},
+		},
+		{
+			name: "later values win",
+			args: []string{"foo", "--label", "env=prod", "--label", "env=stage"},
+			want: map[string]string{"env": "stage"},
+		},
+		{
+			name: "value with equals",
+			args: []string{"foo", "--label", "expr=a=b", "--label", "empty="},
+			want: map[string]string{"expr": "a=b", "empty": ""},
+		},
+		{
+			name: "env",
+			args: []string{"foo"},
+			env:  "env=prod,team=core",
+			want: map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			name: "missing equals",
+			args: []string{"foo", "--label", "env"},
+			err:  `invalid argument for flag --label: missing "=" in entry: "env"`,
+		},
+		{
+			name: "missing key",
+			args: []string{"foo", "--label", "=prod"},
+			err:  `invalid argument for flag --label: missing key in entry: "=prod"`,
+		},
+		{
+			name: "invalid env",
+			args: []string{"foo"},
+			env:  "env=prod,team",
+			err:  `invalid argument for env var FLAG_LABELS: missing "=" in entry: "team"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_LABELS", tt.env)
+			}
+
+			labels := map[string]string{"env": "dev"}
+			cmd := NewCommand(
+				"foo",
+				StringMapFlag(
+					&labels,
+					"label",
+					append(tt.options, FlagEnv("FLAG_LABELS"))...,
+				),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.DeepEqual(labels, tt.want))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		labels := map[string]string{"team": "core", "env": "dev"}
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			StringMapFlag(&labels, "label"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+		g.Should(be.StringContaining(
+			buf.String(),
+			"--label <key=value>\n          Default: env=dev, team=core\n",
+		))
+	})
+}
+
+func TestCountFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		want int
+		err  string
+	}{
+		{
+			args: []string{"foo"},
+			want: 0,
+		},
+		{
+			args: []string{"foo", "-v"},
+			want: 1,
+		},
+		{
+			args: []string{"foo", "-vvv"},
+			want: 3,
+		},
+		{
+			args: []string{"foo", "-v", "--verbose", "-vq"},
+			want: 3,
+		},
+		{
+			args: []string{"foo", "--verbose=3"},
+			want: 3,
+		},
+		{
+			args: []string{"foo", "-v=2", "-v"},
+			want: 3,
+		},
+		{
+			args: []string{"foo", "--verbose=+1", "-v=+2"},
+			want: 2,
+		},
+		{
+			args: []string{"foo"},
+			env:  "+1",
+			want: 1,
+		},
+		{
+			args: []string{"foo"},
+			env:  "2",
+			want: 2,
+		},
+		{
+			args: []string{"foo", "-v"},
+			env:  "2",
+			want: 1,
+		},
+		{
+			args: []string{"foo", "--verbose=lots"},
+			err:  `invalid argument for flag --verbose: not an integer: "lots"`,
+		},
+		{
+			args: []string{"foo"},
+			env:  "lots",
+			err:  `invalid argument for env var FLAG_VERBOSE: not an integer: "lots"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v env: %s", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_VERBOSE", tt.env)
+			}
+
+			var verbose int
+			cmd := NewCommand(
+				"foo",
+				CountFlag(&verbose, "verbose", FlagShort("v"), FlagEnv("FLAG_VERBOSE")),
+				ToggleFlag("quiet", FlagShort("q")),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.Equal(verbose, tt.want))
+		})
+	}
+
+	t.Run("execute twice", func(t *testing.T) {
+		g := ghost.New(t)
+
+		verbose := 1
+		cmd := NewCommand(
+			"foo",
+			CountFlag(&verbose, "verbose", FlagShort("v")),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "-vv"}))
+		g.Should(be.Equal(verbose, 3))
+
+		g.NoError(cmd.Execute([]string{"foo", "-vv"}))
+		g.Should(be.Equal(verbose, 3))
+	})
+}
+
+func TestEnumFlag(t *testing.T) {
+	type format string
+
+	const (
+		formatJSON  format = "json"
+		formatYAML  format = "yaml"
+		formatTable format = "table"
+	)
+
+	tests := []struct {
+		args       []string
+		wantOutput string
+		wantFormat format
+		err        string
+	}{
+		{
+			args:       []string{"foo"},
+			wantOutput: "json",
+			wantFormat: formatTable,
+		},
+		{
+			args:       []string{"foo", "--output", "yaml", "--format=json"},
+			wantOutput: "yaml",
+			wantFormat: formatJSON,
+		},
+		{
+			args: []string{"foo", "--output", "xml"},
+			err:  `invalid argument for flag --output: "xml" must be one of: json, yaml, table`,
+		},
+		{
+			args: []string{"foo", "--format", "JSON"},
+			err:  `invalid argument for flag --format: "JSON" must be one of: json, yaml, table`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			output := "json"
+			f := formatTable
+			cmd := NewCommand(
+				"foo",
+				EnumFlag(&output, "output", []string{"json", "yaml", "table"}),
+				EnumFlag(&f, "format", []format{formatJSON, formatYAML, formatTable}),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.Equal(output, tt.wantOutput))
+			g.Should(be.Equal(f, tt.wantFormat))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		output := "json"
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			EnumFlag(
+				&output,
+				"output",
+				[]string{"json", "yaml", "table"},
+				FlagDescription("Output format"),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+		g.Should(be.StringContaining(buf.String(), `
+      --output <string>
+          Output format
+
+          One of: json, yaml, table
+          Default: json
+`))
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(
+				recover(),
+				`flag "format" has a default value that is not a choice: "xml"`,
+			))
+		}()
+
+		format := "xml"
+		NewCommand("foo", EnumFlag(&format, "format", []string{"json"}))
+	})
+}
+
+type testPoint struct{ x, y int }
+
+func parseTestPoint(s string) (testPoint, error) {
+	var p testPoint
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.x, &p.y); err != nil {
+		return testPoint{}, fmt.Errorf("not a point: %q", s)
+	}
+	return p, nil
+}
+
+func formatTestPoint(p testPoint) string {
+	return fmt.Sprintf("%d,%d", p.x, p.y)
+}
+
+func TestFlag(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var p testPoint
+		cmd := NewCommand(
+			"foo",
+			Flag(&p, "point", parseTestPoint, formatTestPoint),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "--point", "3,4"}))
+		g.Should(be.Equal(p, testPoint{3, 4}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		g := ghost.New(t)
+
+		cmd := NewCommand(
+			"foo",
+			Flag(new(testPoint), "point", parseTestPoint, formatTestPoint),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		err := cmd.Execute([]string{"foo", "--point", "3"})
+		g.Should(be.ErrorEqual(err, `invalid argument for flag --point: not a point: "3"`))
+	})
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		origin := testPoint{1, 2}
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			Flag(&origin, "origin", parseTestPoint, formatTestPoint),
+			Flag(new(testPoint), "zero", parseTestPoint, formatTestPoint),
+			Flag(&origin, "no-format", parseTestPoint, nil),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+
+		output := buf.String()
+		g.Should(be.StringContaining(output, "--origin <value>\n          Default: 1,2\n"))
+		g.Should(be.StringContaining(output, "--zero <value>\n"))
+		g.Should(be.StringContaining(output, "--no-format <value>\n\n"))
+	})
+}
+
+func TestVarFlag(t *testing.T) {
+	t.Run("registered", func(t *testing.T) {
+		g := ghost.New(t)
+
+		RegisterFlagType("point", parseTestPoint, formatTestPoint)
+
+		p := testPoint{1, 1}
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			VarFlag(&p, "point"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "--help"}))
+		g.Should(be.StringContaining(buf.String(), "--point <point>\n          Default: 1,1\n"))
+
+		g.NoError(cmd.Execute([]string{"foo", "--point=5,6", "--help"}))
+		g.Should(be.Equal(p, testPoint{5, 6}))
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), "no flag type registered for complex128"))
+		}()
+
+		VarFlag(new(complex128), "value")
+	})
+}
+
+func TestFlagRequired(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		err  string
+	}{
+		{
+			args: []string{"foo", "--name", "alice", "--token", "abc"},
+		},
+		{
+			args: []string{"foo", "--name", "alice"},
+			env:  "abc",
+		},
+		{
+			args: []string{"foo", "--name", "alice"},
+			err:  "missing required flag: --token",
+		},
+		{
+			args: []string{"foo"},
+			err:  "missing required flags: --name, --token",
+		},
+		{
+			args: []string{"foo", "--help"},
+		},
+		{
+			args: []string{"foo", "--name", "alice", "--token", "abc", "child"},
+			err:  "missing required flag: --id",
+		},
+		{
+			args: []string{"foo", "--name", "alice", "--token", "abc", "child", "--id", "1"},
+		},
+		{
+			args: []string{"foo", "child", "--id", "1"},
+			err:  "missing required flags: --name, --token",
+		},
+		{
+			args: []string{"foo", "child", "--help"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v env: %s", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_TOKEN", tt.env)
+			}
+
+			cmd := NewCommand(
+				"foo",
+				CommandStdout(new(bytes.Buffer)),
+				StringFlag(new(string), "name", FlagRequired),
+				StringFlag(new(string), "token", FlagRequired, FlagEnv("FLAG_TOKEN")),
+				StringFlag(new(string), "optional"),
+				CommandAction(func(*Context) error { return nil }),
+				SubCommand(
+					"child",
+					CommandStdout(new(bytes.Buffer)),
+					IntFlag(new(int), "id", FlagRequired),
+					CommandAction(func(*Context) error { return nil }),
+				),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				return
+			}
+
+			g.NoError(err)
+		})
+	}
+}
+
+func TestFlagGroups(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"foo", "--file", "x"},
+		},
+		{
+			args: []string{"foo", "--stdin", "--json"},
+		},
+		{
+			args: []string{"foo", "--file", "x", "--user", "u", "--password", "p"},
+		},
+		{
+			args: []string{"foo", "--file", "x", "--json", "--yaml"},
+			err:  "flags cannot be used together: --json, --yaml",
+		},
+		{
+			args: []string{"foo", "--file", "x", "--json", "--yaml", "--table"},
+			err:  "flags cannot be used together: --json, --yaml, --table",
+		},
+		{
+			args: []string{"foo", "--file", "x", "--user", "u"},
+			err:  "flag --user requires --password",
+		},
+		{
+			args: []string{"foo", "--file", "x", "--password", "p"},
+			err:  "flag --password requires --user",
+		},
+		{
+			args: []string{"foo"},
+			err:  "one of these flags is required: --file, --stdin",
+		},
+		{
+			args: []string{"foo", "--help"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			cmd := NewCommand(
+				"foo",
+				CommandStdout(new(bytes.Buffer)),
+				ToggleFlag("json"),
+				ToggleFlag("yaml"),
+				ToggleFlag("table"),
+				StringFlag(new(string), "user"),
+				StringFlag(new(string), "password"),
+				StringFlag(new(string), "file"),
+				ToggleFlag("stdin"),
+				FlagsMutuallyExclusive("json", "yaml", "table"),
+				FlagsRequiredTogether("user", "password"),
+				FlagsOneRequired("file", "stdin"),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				return
+			}
+
+			g.NoError(err)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			FlagsMutuallyExclusive("json", "yaml"),
+			ToggleFlag("json"),
+			ToggleFlag("yaml"),
+			StringFlag(new(string), "user"),
+			StringFlag(new(string), "password"),
+			FlagsRequiredTogether("user", "password"),
+			FlagsOneRequired("json", "user"),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "--help"}))
+		g.Should(be.StringContaining(buf.String(), `
+      --yaml
+
+Constraints:
+  Only one of --json, --yaml can be used
+  --user, --password must be used together
+  One of --json, --user is required
+`))
+	})
+
+	t.Run("undefined flag", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), "a flag group refers to an undefined flag: --yaml"))
+		}()
+
+		NewCommand(
+			"foo",
+			ToggleFlag("json"),
+			FlagsMutuallyExclusive("json", "yaml"),
+		)
+	})
+
+	t.Run("single flag", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), "a flag group requires at least two flags: --json"))
+		}()
+
+		NewCommand(
+			"foo",
+			ToggleFlag("json"),
+			FlagsOneRequired("json"),
+		)
+	})
+}
+
+func TestFlagNegatable(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		want bool
+		err  string
+	}{
+		{
+			args: []string{"foo"},
+			want: true,
+		},
+		{
+			args: []string{"foo", "--no-cache"},
+			want: false,
+		},
+		{
+			args: []string{"foo", "--no-cache", "--cache"},
+			want: true,
+		},
+		{
+			args: []string{"foo", "--cache=false"},
+			want: false,
+		},
+		{
+			args: []string{"foo"},
+			env:  "false",
+			want: false,
+		},
+		{
+			args: []string{"foo", "--cache"},
+			env:  "false",
+			want: true,
+		},
+		{
+			args: []string{"foo", "--no-cache=true"},
+			err:  "flag --no-cache does not take a value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v env: %s", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_CACHE", tt.env)
+			}
+
+			cache := true
+			cmd := NewCommand(
+				"foo",
+				BoolFlag(&cache, "cache", FlagNegatable, FlagEnv("FLAG_CACHE")),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.Equal(cache, tt.want))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		buf := new(bytes.Buffer)
+		cache := true
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			BoolFlag(&cache, "cache", FlagNegatable, FlagShort("c")),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+
+		output := buf.String()
+		g.Should(be.StringContaining(output, "  -c, --[no-]cache\n          Default: true\n"))
+		g.ShouldNot(be.StringContaining(output, "--no-cache"))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), `a flag with name "no-cache" already exists`))
+		}()
+
+		NewCommand(
+			"foo",
+			ToggleFlag("no-cache"),
+			BoolFlag(new(bool), "cache", FlagNegatable),
+		)
+	})
+
+	t.Run("duplicate after negated name", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), `a flag with name "no-cache" already exists`))
+		}()
+
+		NewCommand(
+			"foo",
+			BoolFlag(new(bool), "cache", FlagNegatable),
+			BoolFlag(new(bool), "no-cache"),
+		)
+	})
+
+	t.Run("non-boolean", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(
+				recover(),
+				`flag "name" cannot be negatable unless it is a boolean flag`,
+			))
+		}()
+
+		NewCommand(
+			"foo",
+			StringFlag(new(string), "name", FlagNegatable),
+		)
+	})
+}
+
+func TestFlagOptionalValue(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantColor string
+		wantArgs  []string
+	}{
+		{
+			args:      []string{"foo"},
+			wantColor: "auto",
+		},
+		{
+			args:      []string{"foo", "--color"},
+			wantColor: "always",
+		},
+		{
+			args:      []string{"foo", "--color=never"},
+			wantColor: "never",
+		},
+		{
+			args:      []string{"foo", "--color", "never"},
+			wantColor: "always",
+			wantArgs:  []string{"never"},
+		},
+		{
+			args:      []string{"foo", "-c"},
+			wantColor: "always",
+		},
+		{
+			args:      []string{"foo", "-c=never"},
+			wantColor: "never",
+		},
+		{
+			args:      []string{"foo", "-c", "never"},
+			wantColor: "always",
+			wantArgs:  []string{"never"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			color := "auto"
+			var args []string
+			cmd := NewCommand(
+				"foo",
+				EnumFlag(
+					&color,
+					"color",
+					[]string{"auto", "always", "never"},
+					FlagOptionalValue("always"),
+					FlagShort("c"),
+				),
+				CommandArgs(&args),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.Equal(color, tt.wantColor))
+			g.Should(be.DeepEqual(args, tt.wantArgs))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			StringFlag(
+				new(string),
+				"color",
+				FlagOptionalValue("always"),
+				FlagPlaceholder("when"),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+		g.Should(be.StringContaining(buf.String(), "      --color[=<when>]\n"))
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		g := ghost.New(t)
+
+		name := "default"
+		var args []string
+		cmd := NewCommand(
+			"foo",
+			StringFlag(&name, "name", FlagOptionalValue("")),
+			CommandArgs(&args),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "--name", "arg"}))
+		g.Should(be.Equal(name, ""))
+		g.Should(be.DeepEqual(args, []string{"arg"}))
+	})
+
+	t.Run("valueless flag", func(t *testing.T) {
+		for _, option := range []CommandOption{
+			BoolFlag(new(bool), "flag", FlagOptionalValue("false")),
+			ToggleFlag("flag", FlagOptionalValue("true")),
+			CountFlag(new(int), "flag", FlagOptionalValue("2")),
+		} {
+			g := ghost.New(t)
+
+			func() {
+				defer func() {
+					g.Should(be.Equal(
+						recover(),
+						`flag "flag" cannot have an optional value since it never requires a value`,
+					))
+				}()
+
+				NewCommand("foo", option)
+			}()
+		}
+	})
+}
+
+func TestFlagPersistent(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantVerbose bool
+		wantRootEnv string
+		wantChild   string
+		err         string
+	}{
+		{
+			args: []string{"app", "deploy"},
+		},
+		{
+			args:        []string{"app", "--verbose", "deploy"},
+			wantVerbose: true,
+		},
+		{
+			args:        []string{"app", "deploy", "--verbose"},
+			wantVerbose: true,
+		},
+		{
+			args:        []string{"app", "deploy", "prod", "-v"},
+			wantVerbose: true,
+		},
+		{
+			args:        []string{"app", "--env", "a", "deploy", "prod"},
+			wantRootEnv: "a",
+		},
+		{
+			// Shadowed by the sub-command's own flag
+			args:      []string{"app", "deploy", "--env", "b"},
+			wantChild: "b",
+		},
+		{
+			args: []string{"app", "deploy", "--local"},
+			err:  "unknown flag: --local",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			var verbose, local bool
+			var rootEnv, childEnv string
+			cmd := NewCommand(
+				"app",
+				BoolFlag(&verbose, "verbose", FlagShort("v"), FlagPersistent),
+				StringFlag(&rootEnv, "env", FlagPersistent),
+				BoolFlag(&local, "local"),
+				SubCommand(
+					"deploy",
+					StringFlag(&childEnv, "env"),
+					SubCommand(
+						"prod",
+						CommandAction(func(*Context) error { return nil }),
+					),
+					CommandAction(func(*Context) error { return nil }),
+				),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.Equal(verbose, tt.wantVerbose))
+			g.Should(be.Equal(rootEnv, tt.wantRootEnv))
+			g.Should(be.Equal(childEnv, tt.wantChild))
+		})
+	}
+
+	t.Run("action", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var gotName string
+		cmd := NewCommand(
+			"app",
+			ToggleFlag(
+				"version",
+				FlagPersistent,
+				FlagAction(func(ctx *Context) error {
+					gotName = ctx.Name()
+					return nil
+				}),
+			),
+			SubCommand(
+				"deploy",
+				CommandAction(func(*Context) error {
+					return errors.New("should not be called")
+				}),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"app", "deploy", "--version"}))
+		g.Should(be.Equal(gotName, "deploy"))
+	})
+
+	t.Run("required", func(t *testing.T) {
+		g := ghost.New(t)
+
+		cmd := NewCommand(
+			"app",
+			StringFlag(new(string), "token", FlagPersistent, FlagRequired),
+			SubCommand("deploy", CommandAction(func(*Context) error { return nil })),
+		)
+
+		g.NoError(cmd.Execute([]string{"app", "deploy", "--token", "abc"}))
+
+		err := cmd.Execute([]string{"app", "deploy"})
+		g.Should(be.ErrorEqual(err, "missing required flag: --token"))
+	})
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"app",
+			CommandStdout(buf),
+			BoolFlag(new(bool), "verbose", FlagShort("v"), FlagPersistent),
+			StringFlag(new(string), "env", FlagPersistent),
+			ToggleFlag("secret", FlagPersistent, FlagHidden),
+			BoolFlag(new(bool), "local"),
+			SubCommand(
+				"deploy",
+				StringFlag(new(string), "env", FlagDescription("Where to deploy")),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"app", "deploy", "--help"}))
+		g.Should(be.Equal(buf.String(), `app deploy
+
+Options:
+      --env <string>
+          Where to deploy
+
+  -h, --help
+          Print help and exit
+
+Global Options:
+  -v, --verbose
+`))
+	})
+
+	t.Run("shadowed short name", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var verbose, version bool
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"app",
+			CommandStdout(buf),
+			BoolFlag(&verbose, "verbose", FlagShort("v"), FlagPersistent),
+			SubCommand(
+				"deploy",
+				BoolFlag(&version, "version", FlagShort("v")),
+				CommandAction(func(*Context) error { return nil }),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"app", "deploy", "-v"}))
+		g.Should(be.False(verbose))
+		g.Should(be.True(version))
+
+		g.NoError(cmd.Execute([]string{"app", "deploy", "--help"}))
+		g.Should(be.Equal(buf.String(), `app deploy
+
+Options:
+  -h, --help
+          Print help and exit
+
+  -v, --version
+
+Global Options:
+      --verbose
+`))
+
+		buf.Reset()
+		g.NoError(cmd.Execute([]string{"app", "--help"}))
+		g.Should(be.StringContaining(buf.String(), "  -v, --verbose\n"))
+	})
+}
+
+func TestFlagPersistentPrecedence(t *testing.T) {
+	tests := []struct {
+		args        []string
+		env         map[string]string
+		config      string
+		wantTags    []string
+		wantVerbose int
+		wantSource  FlagSource
+	}{
+		{
+			args:        []string{"app", "sub"},
+			env:         map[string]string{"TAGS": "a,b", "VERBOSE": "2"},
+			wantTags:    []string{"a", "b"},
+			wantVerbose: 2,
+			wantSource:  FlagSource{Kind: FlagSourceEnv, Name: "TAGS"},
+		},
+		{
+			args:        []string{"app", "sub", "--tag", "x", "-v"},
+			env:         map[string]string{"TAGS": "a,b", "VERBOSE": "2"},
+			wantTags:    []string{"x"},
+			wantVerbose: 1,
+			wantSource:  FlagSource{Kind: FlagSourceCommandLine},
+		},
+		{
+			args:        []string{"app", "--tag", "x", "-v", "sub"},
+			env:         map[string]string{"TAGS": "a,b", "VERBOSE": "2"},
+			wantTags:    []string{"x"},
+			wantVerbose: 1,
+			wantSource:  FlagSource{Kind: FlagSourceCommandLine},
+		},
+		{
+			args:        []string{"app", "sub"},
+			config:      `{"tag": ["a", "b"], "verbose": 2}`,
+			wantTags:    []string{"a", "b"},
+			wantVerbose: 2,
+			wantSource:  FlagSource{Kind: FlagSourceConfig, Name: "tag"},
+		},
+		{
+			args:        []string{"app", "sub", "--tag", "x", "-v"},
+			config:      `{"tag": ["a", "b"], "verbose": 2}`,
+			wantTags:    []string{"x"},
+			wantVerbose: 1,
+			wantSource:  FlagSource{Kind: FlagSourceCommandLine},
+		},
+		{
+			args:        []string{"app", "sub", "--tag", "x", "-v"},
+			env:         map[string]string{"TAGS": "a,b", "VERBOSE": "2"},
+			config:      `{"tag": ["c"], "verbose": 3}`,
+			wantTags:    []string{"x"},
+			wantVerbose: 1,
+			wantSource:  FlagSource{Kind: FlagSourceCommandLine},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %v %s", tt.args, tt.env, tt.config), func(t *testing.T) {
+			g := ghost.New(t)
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var (
+				tags    []string
+				verbose int
+			)
+
+			options := []CommandOption{
+				StringsFlag(&tags, "tag", FlagEnv("TAGS"), FlagPersistent),
+				CountFlag(&verbose, "verbose", FlagShort("v"), FlagEnv("VERBOSE"), FlagPersistent),
+				SubCommand(
+					"sub",
+					CommandAction(func(ctx *Context) error {
+						g.Should(be.Equal(ctx.FlagSource("tag"), tt.wantSource))
+						return nil
+					}),
+				),
+			}
+			if tt.config != "" {
+				options = append(options, CommandValueSource(JSONFileSource(writeConfig(t, tt.config))))
+			}
+
+			g.NoError(NewCommand("app", options...).Execute(tt.args))
+			g.Should(be.DeepEqual(tags, tt.wantTags))
+			g.Should(be.Equal(verbose, tt.wantVerbose))
+		})
+	}
+}
+
+func TestFlagPersistentDeprecatedEnvOverridden(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("OLD", "env")
+
+	var warnings []string
+	cmd := NewCommand(
+		"app",
+		CommandWarningHandler(func(_ *Context, message string) {
+			warnings = append(warnings, message)
+		}),
+		StringFlag(new(string), "old", FlagEnv("OLD"), FlagPersistent, FlagDeprecated("use --new")),
+		SubCommand("sub", CommandAction(func(*Context) error { return nil })),
+	)
+
+	g.NoError(cmd.Execute([]string{"app", "sub", "--old", "cli"}))
+	g.Should(be.DeepEqual(warnings, []string{"flag --old is deprecated: use --new"}))
+}
+
+func TestFlagAlias(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantName    string
+		wantWarning string
+	}{
+		{
+			args:     []string{"foo", "--new-name", "a"},
+			wantName: "a",
+		},
+		{
+			args:     []string{"foo", "--alt-name", "a"},
+			wantName: "a",
+		},
+		{
+			args:        []string{"foo", "--old-name=a"},
+			wantName:    "a",
+			wantWarning: "Warning: --old-name is deprecated, use --new-name\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v", tt.args), func(t *testing.T) {
+			g := ghost.New(t)
+
+			var name string
+			stderr := new(bytes.Buffer)
+			cmd := NewCommand(
+				"foo",
+				CommandStderr(stderr),
+				StringFlag(
+					&name,
+					"new-name",
+					FlagAlias("alt-name"),
+					FlagDeprecatedAlias("old-name"),
+				),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.Equal(name, tt.wantName))
+			g.Should(be.Equal(stderr.String(), tt.wantWarning))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		g := ghost.New(t)
+
+		buf := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStdout(buf),
+			StringFlag(
+				new(string),
+				"new-name",
+				FlagDescription("The name"),
+				FlagAlias("alt-name"),
+				FlagDeprecatedAlias("old-name"),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo"}))
+		g.Should(be.Equal(buf.String(), `foo
+
+Options:
+  -h, --help
+          Print help and exit
+
+      --new-name <string>
+          The name
+
+          Aliases: --alt-name, --old-name (deprecated)
+`))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), `a flag with name "help" already exists`))
+		}()
+
+		NewCommand(
+			"foo",
+			ToggleFlag("help"),
+			ToggleFlag("usage", FlagAlias("help")),
+		)
+	})
+	t.Run("duplicate after alias", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), `a flag with name "old" already exists`))
+		}()
+
+		NewCommand(
+			"foo",
+			StringFlag(new(string), "new", FlagAlias("old")),
+			StringFlag(new(string), "old"),
+		)
+	})
+
+	t.Run("duplicate after deprecated alias", func(t *testing.T) {
+		g := ghost.New(t)
+
+		defer func() {
+			g.Should(be.Equal(recover(), `a flag with name "old" already exists`))
+		}()
+
+		NewCommand(
+			"foo",
+			StringFlag(new(string), "new", FlagDeprecatedAlias("old")),
+			StringFlag(new(string), "old"),
+		)
+	})
+}
+
+func TestFlagDeprecatedWarnings(t *testing.T) {
+	tests := []struct {
+		args        []string
+		env         string
+		wantWarning string
+	}{
+		{
+			args: []string{"foo"},
+		},
+		{
+			args:        []string{"foo", "--loud"},
+			wantWarning: "Warning: flag --loud is deprecated: Don't be loud.\n",
+		},
+		{
+			args:        []string{"foo", "-l", "-l"},
+			wantWarning: "Warning: flag --loud is deprecated: Don't be loud.\n",
+		},
+		{
+			args: []string{"foo"},
+			env:  "1",
+			wantWarning: "Warning: flag --loud (set by env var FLAG_LOUD) is deprecated: " +
+				"Don't be loud.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v env: %s", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_LOUD", tt.env)
+			}
+
+			stderr := new(bytes.Buffer)
+			cmd := NewCommand(
+				"foo",
+				CommandStderr(stderr),
+				BoolFlag(
+					new(bool),
+					"loud",
+					FlagShort("l"),
+					FlagEnv("FLAG_LOUD"),
+					FlagDeprecated("Don't be loud."),
+				),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.Equal(stderr.String(), tt.wantWarning))
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		g := ghost.New(t)
+
+		wasCalled := false
+		cmd := NewCommand(
+			"foo",
+			CommandStrictDeprecations(true),
+			SubCommand(
+				"bar",
+				StringFlag(new(string), "name", FlagDeprecatedAlias("old-name")),
+				CommandAction(func(*Context) error {
+					wasCalled = true
+					return nil
+				}),
+			),
+		)
+
+		err := cmd.Execute([]string{"foo", "bar", "--old-name", "x"})
+		g.Should(be.ErrorEqual(err, "--old-name is deprecated, use --name"))
+		g.Should(be.Equal(exitCode(err), 2))
+		g.Should(be.False(wasCalled))
+
+		g.NoError(cmd.Execute([]string{"foo", "bar", "--name", "x"}))
+		g.Should(be.True(wasCalled))
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		g := ghost.New(t)
+
+		var warnings []string
+		stderr := new(bytes.Buffer)
+		cmd := NewCommand(
+			"foo",
+			CommandStderr(stderr),
+			CommandWarningHandler(func(ctx *Context, message string) {
+				warnings = append(warnings, ctx.Name()+": "+message)
+			}),
+			SubCommand(
+				"bar",
+				ToggleFlag("loud", FlagDeprecated("Don't be loud.")),
+				CommandAction(func(*Context) error { return nil }),
+			),
+		)
+
+		g.NoError(cmd.Execute([]string{"foo", "bar", "--loud"}))
+		g.Should(be.DeepEqual(warnings, []string{
+			"bar: flag --loud is deprecated: Don't be loud.",
+		}))
+		g.Should(be.Zero(stderr.String()))
+	})
+}
+
+func TestFlagValidate(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		err  string
+	}{
+		{
+			args: []string{"foo"},
+		},
+		{
+			args: []string{"foo", "--port", "8080"},
+		},
+		{
+			args: []string{"foo", "--port", "70000"},
+			err:  "invalid argument for flag --port: must be between 1 and 65535",
+		},
+		{
+			args: []string{"foo"},
+			env:  "0",
+			err:  "invalid argument for flag --port: must be between 1 and 65535",
+		},
+		{
+			args: []string{"foo", "--port", "22"},
+			err:  "invalid argument for flag --port: port 22 is reserved",
+		},
+		{
+			args: []string{"foo", "--port", "70000", "--help"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("args: %v env: %s", tt.args, tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("FLAG_PORT", tt.env)
+			}
+
+			wasCalled := false
+			var port int
+			cmd := NewCommand(
+				"foo",
+				CommandStdout(new(bytes.Buffer)),
+				IntFlag(
+					&port,
+					"port",
+					FlagEnv("FLAG_PORT"),
+					FlagValidate(func(*Context) error {
+						if port < 1 || port > 65535 {
+							return errors.New("must be between 1 and 65535")
+						}
+						return nil
+					}),
+					FlagValidate(func(*Context) error {
+						if port == 22 {
+							return errors.New("port 22 is reserved")
+						}
+						return nil
+					}),
+				),
+				CommandAction(func(*Context) error {
+					wasCalled = true
+					return nil
+				}),
+			)
+
+			err := cmd.Execute(tt.args)
+			if tt.err != "" {
+				g.Should(be.ErrorEqual(err, tt.err))
+				g.Should(be.Equal(exitCode(err), 2))
+				g.Should(be.False(wasCalled))
+				return
+			}
+
+			g.NoError(err)
+		})
+	}
+}
+
+func TestCommandEnvPrefix(t *testing.T) {
+	tests := []struct {
+		env        map[string]string
+		wantName   string
+		wantRegion string
+		wantDryRun bool
+		wantToken  string
+	}{
+		{},
+		{
+			env: map[string]string{
+				"MYAPP_NAME":           "root",
+				"MYAPP_DEPLOY_REGION":  "eu",
+				"MYAPP_DEPLOY_DRY_RUN": "true",
+			},
+			wantName:   "root",
+			wantRegion: "eu",
+			wantDryRun: true,
+		},
+		{
+			env: map[string]string{
+				"MYAPP_DEPLOY_TOKEN": "derived",
+				"DEPLOY_TOKEN":       "explicit",
+			},
+			wantToken: "explicit",
+		},
+		{
+			env: map[string]string{
+				"MYAPP_DEPLOY_HELP":   "true",
+				"MYAPP_DEPLOY_SECRET": "secret",
+				"MYAPP_REGION":        "wrong",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.env), func(t *testing.T) {
+			g := ghost.New(t)
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var (
+				name   string
+				region string
+				dryRun bool
+				token  string
+				secret string
+			)
+
+			wasCalled := false
+			cmd := NewCommand(
+				"app",
+				CommandEnvPrefix("MYAPP"),
+				StringFlag(&name, "name", FlagPersistent),
+				SubCommand(
+					"deploy",
+					StringFlag(&region, "region"),
+					BoolFlag(&dryRun, "dry-run"),
+					StringFlag(&token, "token", FlagEnv("DEPLOY_TOKEN")),
+					StringFlag(&secret, "secret", FlagEnv()),
+					CommandAction(func(*Context) error {
+						wasCalled = true
+						return nil
+					}),
+				),
+			)
+
+			g.NoError(cmd.Execute([]string{"app", "deploy"}))
+			g.Should(be.True(wasCalled))
+			g.Should(be.Equal(name, tt.wantName))
+			g.Should(be.Equal(region, tt.wantRegion))
+			g.Should(be.Equal(dryRun, tt.wantDryRun))
+			g.Should(be.Equal(token, tt.wantToken))
+			g.Should(be.Equal(secret, ""))
+		})
+	}
+}
+
+func TestCommandEnvPrefixSubCommand(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("MYAPP_NAME", "root")
+	t.Setenv("DEPLOY_PROD_REGION", "eu")
+	t.Setenv("DEPLOY_DEPLOY_PROD_REGION", "doubled")
+	t.Setenv("MYAPP_DEPLOY_PROD_REGION", "root-prefix")
+
+	var name, region string
+	wasCalled := false
+	cmd := NewCommand(
+		"app",
+		CommandEnvPrefix("MYAPP"),
+		StringFlag(&name, "name", FlagPersistent),
+		SubCommand(
+			"deploy",
+			CommandEnvPrefix("DEPLOY"),
+			SubCommand(
+				"prod",
+				StringFlag(&region, "region"),
+				CommandAction(func(ctx *Context) error {
+					wasCalled = true
+					info, _ := ctx.LookupFlag("region")
+					g.Should(be.DeepEqual(info.Env, []string{"DEPLOY_PROD_REGION"}))
+					return nil
+				}),
+			),
+		),
+	)
+
+	g.NoError(cmd.Execute([]string{"app", "deploy", "prod"}))
+	g.Should(be.True(wasCalled))
+	g.Should(be.Equal(name, "root"))
+	g.Should(be.Equal(region, "eu"))
+}
@@ -0,0 +1,92 @@
+package clip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// flagGroupKind is a kind of constraint across a group of flags.
+type flagGroupKind int
+
+const (
+	mutuallyExclusive flagGroupKind = iota
+	requiredTogether
+	oneRequired
+)
+
+// flagGroup is a constraint across a group of flags.
+type flagGroup struct {
+	kind  flagGroupKind
+	names []string
+}
+
+// String returns a description of the constraint for use in help docs.
+func (g flagGroup) String() string {
+	flags := g.flags()
+	switch g.kind {
+	case mutuallyExclusive:
+		return fmt.Sprintf("Only one of %s can be used", flags)
+	case requiredTogether:
+		return fmt.Sprintf("%s must be used together", flags)
+	default:
+		return fmt.Sprintf("One of %s is required", flags)
+	}
+}
+
+// flags returns a comma-separated list of the group's flags.
+func (g flagGroup) flags() string {
+	return joinFlagNames(g.names)
+}
+
+// check returns an error if the constraint is not satisfied.
+func (g flagGroup) check(fs *flagSet) error {
+	var set, unset []string
+	for _, name := range g.names {
+		if fs.byName[name].changed {
+			set = append(set, name)
+		} else {
+			unset = append(unset, name)
+		}
+	}
+
+	switch {
+	case g.kind == mutuallyExclusive && len(set) > 1:
+		return fmt.Errorf("flags cannot be used together: %s", joinFlagNames(set))
+	case g.kind == requiredTogether && len(set) > 0 && len(unset) > 0:
+		return fmt.Errorf("flag --%s requires %s", set[0], joinFlagNames(unset))
+	case g.kind == oneRequired && len(set) == 0:
+		return fmt.Errorf("one of these flags is required: %s", g.flags())
+	default:
+		return nil
+	}
+}
+
+// joinFlagNames formats a list of flag names as a comma-separated list.
+func joinFlagNames(names []string) string {
+	return "--" + strings.Join(names, ", --")
+}
+
+// FlagsMutuallyExclusive prevents more than one of the named flags from being
+// set at once.
+func FlagsMutuallyExclusive(names ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.addGroup(flagGroup{kind: mutuallyExclusive, names: names})
+	}
+}
+
+// FlagsRequiredTogether requires that if any of the named flags are set, then
+// all of them are set.
+func FlagsRequiredTogether(names ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.addGroup(flagGroup{kind: requiredTogether, names: names})
+	}
+}
+
+// FlagsOneRequired requires at least one of the named flags to be set.
+//
+// To require exactly one, combine with [FlagsMutuallyExclusive].
+func FlagsOneRequired(names ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.flagSet.addGroup(flagGroup{kind: oneRequired, names: names})
+	}
+}
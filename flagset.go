@@ -1,8 +1,10 @@
 package clip
 
 import (
+	"cmp"
 	"encoding"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
 	"strings"
@@ -21,14 +23,30 @@ func newFlagSet() *flagSet {
 	return &flagSet{
 		byName:      make(map[string]*flagDef),
 		byShortName: make(map[string]*flagDef),
+		dashIndex:   -1,
 	}
 }
 
 type flagSet struct {
-	byName      map[string]*flagDef
-	byShortName map[string]*flagDef
+	name         string
+	byName       map[string]*flagDef
+	byShortName  map[string]*flagDef
+	groups       []flagGroup
+	parent       *flagSet
+	interspersed bool
+	valueSource  ValueSource
+	envFiles     []string
+	envPrefix    string
 
-	args []string
+	args      []string
+	dashIndex int
+	warnings  []string
+	fileEnv   map[string]string
+}
+
+// Warnings returns any warnings about the usage of deprecated flags.
+func (fs *flagSet) Warnings() []string {
+	return fs.warnings
 }
 
 // Args returns non-flag arguments.
@@ -36,29 +54,200 @@ func (fs *flagSet) Args() []string {
 	return fs.args
 }
 
+// ArgsAfterDash returns non-flag arguments passed after a "--" terminator.
+func (fs *flagSet) ArgsAfterDash() []string {
+	if fs.dashIndex < 0 {
+		return nil
+	}
+	return append([]string{}, fs.args[fs.dashIndex:]...)
+}
+
 // Has returns whether a flagset has a flag by a name.
 func (fs *flagSet) Has(name string) bool {
 	_, ok := fs.byName[name]
 	return ok
 }
 
+// lookup finds a flag by name, including persistent flags from parents.
+func (fs *flagSet) lookup(name string) (*flagDef, bool) {
+	for cur := fs; cur != nil; cur = cur.parent {
+		if f, ok := cur.byName[name]; ok && (cur == fs || f.persistent) {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// lookupShort finds a flag by short name, including persistent flags from
+// parents.
+func (fs *flagSet) lookupShort(short string) (*flagDef, bool) {
+	for cur := fs; cur != nil; cur = cur.parent {
+		if f, ok := cur.byShortName[short]; ok && (cur == fs || f.persistent) {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// inherited returns the persistent flags from parents that are available to
+// this flagset, ordered by name.
+func (fs *flagSet) inherited() []*flagDef {
+	var flags []*flagDef
+	for cur := fs.parent; cur != nil; cur = cur.parent {
+		for _, f := range cur.flags() {
+			if found, _ := fs.lookup(f.name); found == f {
+				flags = append(flags, f)
+			}
+		}
+	}
+
+	slices.SortFunc(flags, func(a, b *flagDef) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	return flags
+}
+
+// flags returns each flag in the set once, ordered by name.
+//
+// Flags may be registered under more than one name, such as the negated name
+// of a boolean flag, but only the flag's own name is considered.
+func (fs *flagSet) flags() []*flagDef {
+	var flags []*flagDef
+	for _, name := range slices.Sorted(maps.Keys(fs.byName)) {
+		if f := fs.byName[name]; f.name == name {
+			flags = append(flags, f)
+		}
+	}
+
+	return flags
+}
+
+// configKey returns the key used to look up a flag in a value source.
+//
+// The key is prefixed by the name of each command below the root command.
+func (fs *flagSet) configKey(name string) string {
+	key := name
+	for cur := fs; cur.parent != nil; cur = cur.parent {
+		key = cur.name + "." + key
+	}
+
+	return key
+}
+
+// derivedEnv returns the environment variable for a flag based on the nearest
+// env prefix, including from parents, or an empty string if there is none.
+//
+// The name is the prefix, followed by the name of each command below the
+// command that set the prefix and the flag's name, such as MYAPP_DEPLOY_REGION.
+func (fs *flagSet) derivedEnv(name string) string {
+	key := name
+	for cur := fs; cur != nil; cur = cur.parent {
+		if cur.envPrefix != "" {
+			key = cur.envPrefix + "_" + key
+			return strings.ToUpper(envReplacer.Replace(key))
+		}
+
+		key = cur.name + "_" + key
+	}
+
+	return ""
+}
+
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// lookupValueSource returns the nearest value source, including from parents.
+func (fs *flagSet) lookupValueSource() ValueSource {
+	for cur := fs; cur != nil; cur = cur.parent {
+		if cur.valueSource != nil {
+			return cur.valueSource
+		}
+	}
+
+	return nil
+}
+
 // HasShort returns whether a flagset has a flag by a short name.
 func (fs *flagSet) HasShort(name string) bool {
 	_, ok := fs.byShortName[name]
 	return ok
 }
 
+// addGroup adds a constraint across a group of flags.
+func (fs *flagSet) addGroup(g flagGroup) {
+	if len(g.names) < 2 {
+		panic(fmt.Sprintf("a flag group requires at least two flags: %s", joinFlagNames(g.names)))
+	}
+
+	fs.groups = append(fs.groups, g)
+}
+
+// checkGroupNames panics if a flag group refers to an undefined flag.
+func (fs *flagSet) checkGroupNames() {
+	for _, g := range fs.groups {
+		for _, name := range g.names {
+			if !fs.Has(name) {
+				panic(fmt.Sprintf("a flag group refers to an undefined flag: --%s", name))
+			}
+		}
+	}
+}
+
 // Parse a set of command-line arguments as flags.
+//
+// Flags not passed on the command-line are set later by [flagSet.resolve].
 func (fs *flagSet) Parse(args []string) error {
-	err := fs.parseFlags(args)
+	fs.args = nil
+	fs.dashIndex = -1
+	fs.warnings = nil
+	for _, f := range fs.flags() {
+		f.changed = false
+		f.source = FlagSource{}
+	}
+
+	fileEnv, err := loadEnvFiles(fs.envFiles)
 	if err != nil {
 		return err
 	}
+	fs.fileEnv = fileEnv
+
+	return fs.parseFlags(args)
+}
+
+// resolve sets flags not passed on the command-line from environment variables
+// and value sources.
+//
+// Persistent flags can still be passed on the command-line after the name of
+// a sub-command, so they are resolved only for the final command to be run,
+// along with the persistent flags of each parent.
+func (fs *flagSet) resolve(final bool) error {
+	var flags []*flagDef
+	for _, f := range fs.flags() {
+		if final || !f.persistent {
+			flags = append(flags, f)
+		}
+	}
 
-	for _, f := range fs.byName {
+	if final {
+		for cur := fs.parent; cur != nil; cur = cur.parent {
+			for _, f := range cur.flags() {
+				if f.persistent {
+					flags = append(flags, f)
+				}
+			}
+		}
+	}
+
+	for _, f := range flags {
 		if err := fs.parseEnv(f); err != nil {
 			return err
 		}
+
+		if err := fs.parseValueSource(f); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -71,9 +260,15 @@ func (fs *flagSet) parseFlags(args []string) error {
 
 		switch {
 		case arg == "--":
+			fs.dashIndex = len(fs.args)
 			fs.args = append(fs.args, args...)
 			return nil
 		case len(arg) < 2 || arg[0] != '-':
+			if fs.interspersed {
+				fs.args = append(fs.args, arg)
+				continue
+			}
+
 			fs.args = slices.Grow(fs.args, 1+len(args))
 			fs.args = append(fs.args, arg)
 			fs.args = append(fs.args, args...)
@@ -99,14 +294,22 @@ func (fs *flagSet) parseFlags(args []string) error {
 func (fs *flagSet) parseLong(arg string, args []string) ([]string, error) {
 	name, value, hasEqual := strings.Cut(arg[2:], "=")
 
-	f, ok := fs.byName[name]
+	f, ok := fs.lookup(name)
 	if !ok {
 		return nil, fmt.Errorf("unknown flag: --%s", name)
 	}
 
+	increment := false
 	switch {
+	case f.negatable && name == f.negatedName():
+		if hasEqual {
+			return nil, fmt.Errorf("flag --%s does not take a value", name)
+		}
+		value = "false"
 	case hasEqual:
-	case f.boolVal != "":
+	case f.incFunc != nil:
+		increment = true
+	case f.boolVal != "" || f.optionalValue:
 		value = f.boolVal
 	case len(args) > 0:
 		value, args = args[0], args[1:]
@@ -114,10 +317,18 @@ func (fs *flagSet) parseLong(arg string, args []string) ([]string, error) {
 		return nil, fmt.Errorf("missing argument for flag: --%s", name)
 	}
 
-	if err := f.set(value); err != nil {
+	if increment {
+		fs.increment(f)
+	} else if err := fs.set(f, value, FlagSource{Kind: FlagSourceCommandLine}); err != nil {
 		return nil, fmt.Errorf("invalid argument for flag --%s: %w", name, err)
 	}
 
+	if slices.Contains(f.deprecatedAliases, name) {
+		fs.warnings = append(fs.warnings, fmt.Sprintf(
+			"--%s is deprecated, use --%s", name, f.name,
+		))
+	}
+
 	return args, nil
 }
 
@@ -125,7 +336,7 @@ func (fs *flagSet) parseShort(arg string, args []string) ([]string, error) {
 	for i := 1; i < len(arg); i++ {
 		short := string(arg[i])
 
-		f, ok := fs.byShortName[short]
+		f, ok := fs.lookupShort(short)
 		if !ok {
 			return nil, fmt.Errorf("unknown shorthand flag: '%s' in %s", short, arg)
 		}
@@ -135,11 +346,14 @@ func (fs *flagSet) parseShort(arg string, args []string) ([]string, error) {
 		hasMore := !isLastChar && arg[i+1] != '='
 
 		var value string
+		increment := false
 		switch {
 		case hasEqual:
 			value = arg[i+2:]
 			i = len(arg)
-		case f.boolVal != "":
+		case f.incFunc != nil:
+			increment = true
+		case f.boolVal != "" || f.optionalValue:
 			value = f.boolVal
 		case hasMore:
 			value = arg[i+1:]
@@ -150,7 +364,9 @@ func (fs *flagSet) parseShort(arg string, args []string) ([]string, error) {
 			return nil, fmt.Errorf("missing argument for flag: '%s' in %s", short, arg)
 		}
 
-		if err := f.set(value); err != nil {
+		if increment {
+			fs.increment(f)
+		} else if err := fs.set(f, value, FlagSource{Kind: FlagSourceCommandLine}); err != nil {
 			return nil, fmt.Errorf("invalid argument for flag '%s' in %s: %w", short, arg, err)
 		}
 	}
@@ -158,22 +374,163 @@ func (fs *flagSet) parseShort(arg string, args []string) ([]string, error) {
 	return args, nil
 }
 
+// set assigns a value to a flag from a source.
+func (fs *flagSet) set(f *flagDef, value string, source FlagSource) error {
+	wasChanged := f.changed
+	if err := f.set(value); err != nil {
+		return err
+	}
+
+	fs.record(f, source, wasChanged)
+	return nil
+}
+
+// increment increments a count flag passed on the command-line without a
+// value.
+func (fs *flagSet) increment(f *flagDef) {
+	wasChanged := f.changed
+	f.increment()
+	fs.record(f, FlagSource{Kind: FlagSourceCommandLine}, wasChanged)
+}
+
+// record records the source of a flag's value, and a warning the first time a
+// deprecated flag is set.
+func (fs *flagSet) record(f *flagDef, source FlagSource, wasChanged bool) {
+	f.source = source
+
+	if wasChanged || f.deprecated == "" {
+		return
+	}
+
+	if source.Kind != FlagSourceCommandLine {
+		fs.warnings = append(fs.warnings, fmt.Sprintf(
+			"flag --%s (set by %s) is deprecated: %s", f.name, source, f.deprecated,
+		))
+	} else {
+		fs.warnings = append(fs.warnings, fmt.Sprintf(
+			"flag --%s is deprecated: %s", f.name, f.deprecated,
+		))
+	}
+}
+
 func (fs *flagSet) parseEnv(f *flagDef) error {
 	if f.changed {
 		return nil
 	}
 
-	for _, env := range f.env {
-		v, ok := os.LookupEnv(env)
-		if !ok {
+	for _, env := range f.Env() {
+		v, ok := f.owner.lookupEnv(env)
+		if !ok || (f.repeatable && v == "") {
 			continue
 		}
 
-		if err := f.set(v); err != nil {
-			return fmt.Errorf("invalid argument for env var %s: %w", env, err)
+		values := []string{v}
+		if f.repeatable {
+			values = strings.Split(v, cmp.Or(f.separator, ","))
+		}
+
+		for _, v := range values {
+			if err := fs.set(f, v, FlagSource{Kind: FlagSourceEnv, Name: env}); err != nil {
+				return fmt.Errorf("invalid argument for env var %s: %w", env, err)
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// parseValueSource sets a flag from the nearest value source of the command
+// that defines it, if any.
+//
+// Flags with an action, such as --help, are never set from a value source.
+func (fs *flagSet) parseValueSource(f *flagDef) error {
+	src := f.owner.lookupValueSource()
+	if f.changed || f.action != nil || src == nil {
+		return nil
+	}
+
+	key := f.owner.configKey(f.name)
+	values, ok, err := src.Lookup(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	if len(values) > 1 && !f.repeatable {
+		return fmt.Errorf("invalid argument for config key %s: expected a single value", key)
+	}
+
+	for _, v := range values {
+		if err := fs.set(f, v, FlagSource{Kind: FlagSourceConfig, Name: key}); err != nil {
+			return fmt.Errorf("invalid argument for config key %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv looks up an environment variable, falling back to variables from
+// the env files of this flagset or its parents.
+func (fs *flagSet) lookupEnv(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+
+	for cur := fs; cur != nil; cur = cur.parent {
+		if v, ok := cur.fileEnv[name]; ok {
+			return v, true
 		}
+	}
+
+	return "", false
+}
 
+// checkRequired returns an error if any required flags were not set.
+func (fs *flagSet) checkRequired() error {
+	var missing []string
+	for _, f := range fs.flags() {
+		if f.required && !f.changed {
+			missing = append(missing, "--"+f.name)
+		}
+	}
+
+	switch len(missing) {
+	case 0:
 		return nil
+	case 1:
+		return fmt.Errorf("missing required flag: %s", missing[0])
+	default:
+		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+	}
+}
+
+// checkGroups returns an error if any flag group constraints are violated.
+func (fs *flagSet) checkGroups() error {
+	for _, g := range fs.groups {
+		if err := g.check(fs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate runs the validation functions of each flag that was set.
+func (fs *flagSet) validate(ctx *Context) error {
+	for _, f := range fs.flags() {
+		if !f.changed {
+			continue
+		}
+
+		for _, validate := range f.validators {
+			if err := validate(ctx); err != nil {
+				return fmt.Errorf("invalid argument for flag --%s: %w", f.name, err)
+			}
+		}
 	}
 
 	return nil
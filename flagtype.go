@@ -0,0 +1,66 @@
+package clip
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// flagType is a registered set of functions for creating flags of a type.
+type flagType struct {
+	placeholder string
+	parse       any
+	format      any
+}
+
+var (
+	flagTypesMu sync.RWMutex
+	flagTypes   = map[reflect.Type]flagType{}
+)
+
+// RegisterFlagType registers functions for creating flags of type T.
+//
+// Once registered, flags of the type can be created using [VarFlag]. The
+// placeholder is the name of the value shown in help text, which defaults to
+// "value". Registering a type more than once replaces the earlier functions.
+//
+// Types are typically registered from an init function.
+func RegisterFlagType[T any](
+	placeholder string,
+	parse func(string) (T, error),
+	format func(T) string,
+) {
+	flagTypesMu.Lock()
+	defer flagTypesMu.Unlock()
+
+	flagTypes[reflect.TypeFor[T]()] = flagType{
+		placeholder: "<" + cmp.Or(placeholder, "value") + ">",
+		parse:       parse,
+		format:      format,
+	}
+}
+
+// VarFlag creates a new flag using the functions registered for type T.
+//
+// Panics if the type has not been registered with [RegisterFlagType].
+func VarFlag[T any](value *T, name string, options ...FlagOption) CommandOption {
+	typ := reflect.TypeFor[T]()
+
+	flagTypesMu.RLock()
+	ft, ok := flagTypes[typ]
+	flagTypesMu.RUnlock()
+
+	if !ok {
+		panic(fmt.Sprintf("no flag type registered for %s", typ))
+	}
+
+	return valueFlag(
+		value,
+		name,
+		ft.placeholder,
+		ft.parse.(func(string) (T, error)),
+		ft.format.(func(T) string),
+		options...,
+	)
+}
@@ -4,8 +4,6 @@ import (
 	_ "embed"
 	"fmt"
 	"io"
-	"maps"
-	"slices"
 	"strings"
 	"text/template"
 )
@@ -19,9 +17,17 @@ func newHelpContext(ctx *Context) *helpContext {
 		}
 	}
 
+	maxArgNameLen := 0
+	for _, arg := range ctx.command.args {
+		if len(arg.Name()) > maxArgNameLen {
+			maxArgNameLen = len(arg.Name())
+		}
+	}
+
 	return &helpContext{
 		Context:       ctx,
 		maxCmdNameLen: maxCmdNameLen,
+		maxArgNameLen: maxArgNameLen,
 	}
 }
 
@@ -30,6 +36,7 @@ type helpContext struct {
 	*Context
 
 	maxCmdNameLen int
+	maxArgNameLen int
 }
 
 func (ctx *helpContext) FullName() string {
@@ -42,16 +49,33 @@ func (ctx *helpContext) FullName() string {
 	return name
 }
 
+// Usage is the usage line for the command, if the command takes arguments.
+func (ctx *helpContext) Usage() string {
+	if len(ctx.command.args) == 0 && ctx.command.restArgs == nil {
+		return ""
+	}
+
+	usage := ctx.FullName()
+	for _, arg := range ctx.command.args {
+		usage += " " + arg.Usage()
+	}
+	if ctx.command.restArgs != nil {
+		usage += " [<arg>...]"
+	}
+
+	return usage
+}
+
+// PositionalArgs is the list of positional arguments in order.
+func (ctx *helpContext) PositionalArgs() []*argDef { return ctx.command.args }
+
 // VisibleCommands is the list of sub-commands in order.
 func (ctx *helpContext) VisibleCommands() []*Command { return ctx.command.visibleCommands }
 
 // VisibleFlags is the list of flags in order.
 func (ctx *helpContext) VisibleFlags() []*flagDef {
-	flagNames := slices.Sorted(maps.Keys(ctx.command.flagSet.byName))
-
 	var flags []*flagDef
-	for _, name := range flagNames {
-		flag := ctx.command.flagSet.byName[name]
+	for _, flag := range ctx.command.flagSet.flags() {
 		if !flag.Hidden() {
 			flags = append(flags, flag)
 		}
@@ -60,6 +84,39 @@ func (ctx *helpContext) VisibleFlags() []*flagDef {
 	return flags
 }
 
+// GlobalFlags is the list of persistent flags inherited from parents in order.
+//
+// Short names used by a different flag are left out, since passing them sets
+// the other flag instead.
+func (ctx *helpContext) GlobalFlags() []*flagDef {
+	var flags []*flagDef
+	for _, flag := range ctx.command.flagSet.inherited() {
+		if flag.Hidden() {
+			continue
+		}
+
+		if found, _ := ctx.command.flagSet.lookupShort(flag.short); flag.short != "" && found != flag {
+			shadowed := *flag
+			shadowed.short = ""
+			flag = &shadowed
+		}
+
+		flags = append(flags, flag)
+	}
+
+	return flags
+}
+
+// FlagConstraints is the list of constraints across groups of flags.
+func (ctx *helpContext) FlagConstraints() []string {
+	constraints := make([]string, 0, len(ctx.command.flagSet.groups))
+	for _, g := range ctx.command.flagSet.groups {
+		constraints = append(constraints, g.String())
+	}
+
+	return constraints
+}
+
 //go:embed help.tmpl
 var helpTemplate string
 
@@ -69,6 +126,7 @@ func WriteHelp(w io.Writer, ctx *Context) error {
 	t := template.New("help").Funcs(template.FuncMap{
 		"join":           stringsJoin,
 		"pad":            pad,
+		"padArg":         getArgPadder(hctx),
 		"padCommand":     getCommandPadder(hctx),
 		"printFlagShort": printFlagShort,
 	})
@@ -83,6 +141,13 @@ func getCommandPadder(ctx *helpContext) func(string) string {
 	}
 }
 
+func getArgPadder(ctx *helpContext) func(string) string {
+	s := fmt.Sprintf("%%-%ds", ctx.maxArgNameLen+2)
+	return func(text string) string {
+		return fmt.Sprintf(s, text)
+	}
+}
+
 func printFlagShort(short string) string {
 	if short == "" {
 		return "    "
@@ -133,3 +133,157 @@ func Test_printCommandHelp_placeholder(t *testing.T) {
 	g.Should(be.StringContaining(output, "--default-bool\n"))
 	g.Should(be.StringContaining(output, "--override-bool=<somebool>"))
 }
+
+func TestHelpArgs(t *testing.T) {
+	g := ghost.New(t)
+
+	buf := new(bytes.Buffer)
+	root := NewCommand(
+		"my-app",
+		SubCommand(
+			"hello",
+			CommandStdout(buf),
+			CommandSummary("Greet the world"),
+			StringArg(
+				new(string),
+				"name",
+				ArgOptional,
+				ArgSummary("The person to greet"),
+				ArgValues([]string{"Alice", "Bruce", "Carl"}),
+			),
+			StringArg(
+				new(string),
+				"greeting",
+				ArgOptional,
+				ArgValues([]string{"Hello", "Hi"}),
+			),
+		),
+	)
+
+	g.NoError(root.Execute([]string{"my-app", "hello", "--help"}))
+
+	g.Should(be.Equal(buf.String(), `my-app hello - Greet the world
+
+Usage:
+  my-app hello [<name>] [<greeting>]
+
+Args:
+  name      The person to greet
+            One of: Alice, Bruce, Carl
+  greeting  One of: Hello, Hi
+
+Options:
+  -h, --help
+          Print help and exit
+`))
+}
+
+func TestHelpArgsUsage(t *testing.T) {
+	tests := []struct {
+		option CommandOption
+		want   string
+	}{
+		{
+			option: StringArg(new(string), "file"),
+			want:   "foo <file>",
+		},
+		{
+			option: StringArg(new(string), "file", ArgOptional),
+			want:   "foo [<file>]",
+		},
+		{
+			option: StringsArg(new([]string), "file"),
+			want:   "foo <file>...",
+		},
+		{
+			option: StringsArg(new([]string), "file", ArgOptional),
+			want:   "foo [<file>...]",
+		},
+		{
+			option: StringsArg(new([]string), "file", ArgMin(2)),
+			want:   "foo <file>...",
+		},
+		{
+			option: CommandArgs(new([]string)),
+			want:   "foo [<arg>...]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			g := ghost.New(t)
+
+			buf := new(bytes.Buffer)
+			cmd := NewCommand("foo", CommandStdout(buf), tt.option)
+
+			g.NoError(cmd.Execute([]string{"foo", "--help"}))
+			g.Should(be.StringContaining(buf.String(), "Usage:\n  "+tt.want+"\n"))
+		})
+	}
+}
+
+func Test_printCommandHelp_required(t *testing.T) {
+	g := ghost.New(t)
+
+	buf := new(bytes.Buffer)
+	root := NewCommand(
+		"root",
+		CommandStdout(buf),
+		StringFlag(new(string), "name", FlagRequired, FlagDescription("Who to greet")),
+	)
+
+	g.NoError(root.Execute([]string{root.Name(), "--help"}))
+	g.Should(be.StringContaining(buf.String(), `
+      --name <string> (required)
+          Who to greet
+`))
+}
+
+func Test_printCommandHelp_config(t *testing.T) {
+	g := ghost.New(t)
+
+	buf := new(bytes.Buffer)
+	root := NewCommand(
+		"root",
+		CommandStdout(buf),
+		CommandValueSource(JSONFileSource("config.json")),
+		SubCommand(
+			"deploy",
+			StringFlag(new(string), "region", FlagEnv("REGION")),
+		),
+	)
+
+	g.NoError(root.Execute([]string{root.Name(), "deploy", "--help"}))
+	g.Should(be.StringContaining(buf.String(), `
+      --region <string>
+          Env: REGION
+          Config: deploy.region
+`))
+	g.ShouldNot(be.StringContaining(buf.String(), "Config: deploy.help"))
+}
+
+func Test_printCommandHelp_envPrefix(t *testing.T) {
+	g := ghost.New(t)
+
+	buf := new(bytes.Buffer)
+	root := NewCommand(
+		"root",
+		CommandStdout(buf),
+		CommandEnvPrefix("MYAPP"),
+		SubCommand(
+			"deploy",
+			StringFlag(new(string), "region"),
+			StringFlag(new(string), "token", FlagEnv("DEPLOY_TOKEN")),
+		),
+	)
+
+	g.NoError(root.Execute([]string{root.Name(), "deploy", "--help"}))
+	g.Should(be.StringContaining(buf.String(), `
+      --region <string>
+          Env: MYAPP_DEPLOY_REGION
+
+      --token <string>
+          Env: DEPLOY_TOKEN
+`))
+	g.ShouldNot(be.StringContaining(buf.String(), "MYAPP_DEPLOY_HELP"))
+}
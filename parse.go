@@ -0,0 +1,78 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseInt parses a base-10 integer of the given bit size.
+func parseInt(s string, bitSize int) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("integer out of range: %q", s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("not an integer: %q", s)
+	}
+
+	return n, nil
+}
+
+// parseUint parses a base-10 unsigned integer of the given bit size.
+func parseUint(s string, bitSize int) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("integer out of range: %q", s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("not an unsigned integer: %q", s)
+	}
+
+	return n, nil
+}
+
+// parseFloat parses a floating-point number.
+func parseFloat(s string) (float64, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("number out of range: %q", s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("not a number: %q", s)
+	}
+
+	return n, nil
+}
+
+// parseDuration parses a duration such as "1m30s".
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("not a duration: %q", s)
+	}
+
+	return d, nil
+}
+
+// parseTime parses a time using the given layout.
+func parseTime(layout, s string) (time.Time, error) {
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a time matching %q: %q", layout, s)
+	}
+
+	return t, nil
+}
+
+// checkChoice returns an error if a value is not one of the allowed choices.
+func checkChoice(s string, choices []string) error {
+	if slices.Contains(choices, s) {
+		return nil
+	}
+
+	return fmt.Errorf("%q must be one of: %s", s, strings.Join(choices, ", "))
+}
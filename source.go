@@ -0,0 +1,210 @@
+package clip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FlagSourceKind is the kind of place a flag's value came from.
+type FlagSourceKind int
+
+const (
+	// FlagSourceDefault means the flag was not set, and has its default value.
+	FlagSourceDefault FlagSourceKind = iota
+	// FlagSourceCommandLine means the flag was passed on the command-line.
+	FlagSourceCommandLine
+	// FlagSourceEnv means the flag was set by an environment variable.
+	FlagSourceEnv
+	// FlagSourceConfig means the flag was set by a [ValueSource], such as a
+	// config file.
+	FlagSourceConfig
+)
+
+// FlagSource describes where a flag's value came from.
+type FlagSource struct {
+	Kind FlagSourceKind
+
+	// Name is the name of the environment variable for [FlagSourceEnv], or
+	// the key for [FlagSourceConfig].
+	Name string
+}
+
+// String returns a human-readable description of the source.
+func (s FlagSource) String() string {
+	switch s.Kind {
+	case FlagSourceCommandLine:
+		return "command line"
+	case FlagSourceEnv:
+		return "env var " + s.Name
+	case FlagSourceConfig:
+		return "config key " + s.Name
+	default:
+		return "default"
+	}
+}
+
+// writeFlagSources writes the value and source of each flag available to a
+// command.
+//
+// Hidden flags and flags with an action, such as --help, are not included, and
+// the values of sensitive flags are masked.
+func writeFlagSources(w io.Writer, ctx *Context) error {
+	flags := ctx.command.flagSet.flags()
+	flags = append(flags, ctx.command.flagSet.inherited()...)
+
+	for _, f := range flags {
+		if f.hidden || f.action != nil {
+			continue
+		}
+
+		value := f.value()
+		if f.sensitive {
+			value = "<redacted>"
+		}
+
+		if _, err := fmt.Fprintf(w, "--%s=%s (%s)\n", f.name, value, f.source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValueSource provides flag values from outside the command-line, such as a
+// config file.
+//
+// Value sources are consulted for flags not set on the command-line or by an
+// environment variable. See [CommandValueSource].
+type ValueSource interface {
+	// Lookup returns the values for a key, and whether the key was found.
+	//
+	// Keys are the flag's name, prefixed by the name of each sub-command
+	// below the root command and separated by dots, such as "deploy.region".
+	// Only flags that accept multiple values may have more than one value.
+	Lookup(key string) (values []string, ok bool, err error)
+}
+
+// JSONFileSource returns a value source that reads a JSON file.
+//
+// Each dot-separated part of a key is a nested object, so the key
+// "deploy.region" reads {"deploy": {"region": "eu"}}. Values may be strings,
+// numbers, or booleans. Arrays can be used for flags that accept multiple
+// values, and objects for flags that accept key/value pairs.
+//
+// The file is read once, the first time a value is looked up. A missing file
+// is treated as an empty config.
+func JSONFileSource(path string) ValueSource {
+	return &jsonFileSource{path: path}
+}
+
+type jsonFileSource struct {
+	path string
+
+	once sync.Once
+	data map[string]any
+	err  error
+}
+
+func (s *jsonFileSource) Lookup(key string) ([]string, bool, error) {
+	s.once.Do(s.load)
+	if s.err != nil {
+		return nil, false, s.err
+	}
+
+	var value any = s.data
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+
+		value, ok = obj[part]
+		if !ok {
+			return nil, false, nil
+		}
+	}
+
+	values, err := jsonValues(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid value for config key %s: %w", key, err)
+	}
+
+	return values, values != nil, nil
+}
+
+func (s *jsonFileSource) load() {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		s.err = fmt.Errorf("reading config file: %w", err)
+		return
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&s.data); err != nil {
+		s.err = fmt.Errorf("parsing config file %s: %w", s.path, err)
+	}
+}
+
+// jsonValues converts a decoded JSON value to a list of flag values.
+//
+// Null values are treated as unset, and return no values.
+func jsonValues(value any) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			s, err := jsonScalar(v)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	case map[string]any:
+		values := make([]string, 0, len(value))
+		for _, k := range slices.Sorted(maps.Keys(value)) {
+			s, err := jsonScalar(value[k])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, k+"="+s)
+		}
+		return values, nil
+	default:
+		s, err := jsonScalar(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+}
+
+// jsonScalar converts a decoded JSON string, number, or boolean to a string.
+func jsonScalar(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	default:
+		return "", fmt.Errorf("unsupported value: %v", value)
+	}
+}
@@ -0,0 +1,217 @@
+package clip
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestValueSourcePrecedence(t *testing.T) {
+	tests := []struct {
+		args       []string
+		env        string
+		config     string
+		wantRegion string
+		wantSource FlagSource
+	}{
+		{
+			args:       []string{"app", "deploy"},
+			config:     `{}`,
+			wantRegion: "default",
+			wantSource: FlagSource{Kind: FlagSourceDefault},
+		},
+		{
+			args:       []string{"app", "deploy"},
+			config:     `{"deploy": {"region": "config"}}`,
+			wantRegion: "config",
+			wantSource: FlagSource{Kind: FlagSourceConfig, Name: "deploy.region"},
+		},
+		{
+			args:       []string{"app", "deploy"},
+			env:        "env",
+			config:     `{"deploy": {"region": "config"}}`,
+			wantRegion: "env",
+			wantSource: FlagSource{Kind: FlagSourceEnv, Name: "APP_REGION"},
+		},
+		{
+			args:       []string{"app", "deploy", "--region", "cli"},
+			env:        "env",
+			config:     `{"deploy": {"region": "config"}}`,
+			wantRegion: "cli",
+			wantSource: FlagSource{Kind: FlagSourceCommandLine},
+		},
+		{
+			args:       []string{"app", "deploy"},
+			config:     `{"region": "wrong-key"}`,
+			wantRegion: "default",
+			wantSource: FlagSource{Kind: FlagSourceDefault},
+		},
+		{
+			args:       []string{"app", "deploy"},
+			config:     `{"deploy": {"region": null}}`,
+			wantRegion: "default",
+			wantSource: FlagSource{Kind: FlagSourceDefault},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s %s", tt.args, tt.env, tt.config), func(t *testing.T) {
+			g := ghost.New(t)
+
+			if tt.env != "" {
+				t.Setenv("APP_REGION", tt.env)
+			}
+
+			wasCalled := false
+			region := "default"
+			cmd := NewCommand(
+				"app",
+				CommandValueSource(JSONFileSource(writeConfig(t, tt.config))),
+				SubCommand(
+					"deploy",
+					StringFlag(&region, "region", FlagEnv("APP_REGION")),
+					CommandAction(func(ctx *Context) error {
+						wasCalled = true
+						g.Should(be.Equal(ctx.FlagSource("region"), tt.wantSource))
+						return nil
+					}),
+				),
+			)
+
+			g.NoError(cmd.Execute(tt.args))
+			g.Should(be.True(wasCalled))
+			g.Should(be.Equal(region, tt.wantRegion))
+		})
+	}
+}
+
+func TestJSONFileSource(t *testing.T) {
+	g := ghost.New(t)
+
+	path := writeConfig(t, `{
+		"verbose": true,
+		"deploy": {
+			"count": 3,
+			"ratio": 0.5,
+			"tags": ["a", "b"],
+			"labels": {"team": "core", "tier": 1}
+		}
+	}`)
+
+	var (
+		verbose bool
+		count   int
+		ratio   float64
+		tags    = []string{"default"}
+		labels  map[string]string
+	)
+
+	wasCalled := false
+	cmd := NewCommand(
+		"app",
+		CommandValueSource(JSONFileSource(path)),
+		BoolFlag(&verbose, "verbose", FlagPersistent),
+		SubCommand(
+			"deploy",
+			IntFlag(&count, "count"),
+			Float64Flag(&ratio, "ratio"),
+			StringsFlag(&tags, "tags"),
+			StringMapFlag(&labels, "labels"),
+			CommandAction(func(*Context) error {
+				wasCalled = true
+				return nil
+			}),
+		),
+	)
+
+	g.NoError(cmd.Execute([]string{"app", "deploy"}))
+	g.Should(be.True(wasCalled))
+	g.Should(be.True(verbose))
+	g.Should(be.Equal(count, 3))
+	g.Should(be.Equal(ratio, 0.5))
+	g.Should(be.DeepEqual(tags, []string{"a", "b"}))
+	g.Should(be.DeepEqual(labels, map[string]string{"team": "core", "tier": "1"}))
+}
+
+func TestJSONFileSourceMissing(t *testing.T) {
+	g := ghost.New(t)
+
+	name := "default"
+	cmd := NewCommand(
+		"app",
+		CommandValueSource(JSONFileSource(filepath.Join(t.TempDir(), "missing.json"))),
+		StringFlag(&name, "name"),
+		CommandAction(func(*Context) error { return nil }),
+	)
+
+	g.NoError(cmd.Execute([]string{"app"}))
+	g.Should(be.Equal(name, "default"))
+}
+
+func TestJSONFileSourceErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{
+			config: `{"count": "many"}`,
+			err:    `invalid argument for config key count: not an integer: "many"`,
+		},
+		{
+			config: `{"count": [1, 2]}`,
+			err:    "invalid argument for config key count: expected a single value",
+		},
+		{
+			config: `{"count": [[1]]}`,
+			err:    "invalid value for config key count: unsupported value: [1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.config, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var count int
+			cmd := NewCommand(
+				"app",
+				CommandValueSource(JSONFileSource(writeConfig(t, tt.config))),
+				IntFlag(&count, "count"),
+				CommandAction(func(*Context) error { return nil }),
+			)
+
+			err := cmd.Execute([]string{"app"})
+			g.Should(be.ErrorEqual(err, tt.err))
+			g.Should(be.Equal(exitCode(err), 2))
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		g := ghost.New(t)
+
+		path := writeConfig(t, `{`)
+		cmd := NewCommand(
+			"app",
+			CommandValueSource(JSONFileSource(path)),
+			IntFlag(new(int), "count"),
+			CommandAction(func(*Context) error { return nil }),
+		)
+
+		err := cmd.Execute([]string{"app"})
+		g.Should(be.ErrorContaining(err, "parsing config file "+path))
+	})
+}